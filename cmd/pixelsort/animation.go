@@ -0,0 +1,185 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"io"
+
+	"github.com/dcormier/go-pixelsort/quantize"
+	"github.com/dcormier/go-pixelsort/sortablecolor"
+)
+
+// runAnimation sorts every frame of an animated GIF, and writes them out as an animated GIF
+func runAnimation(opts *options, anim *gif.GIF) error {
+	how := "each frame on its own"
+	if opts.coherent {
+		how = "whole frames, coherently"
+	}
+
+	fmt.Fprintf(messages, "Sorting %s by %v (%s, %s)\n", how, opts.combiner.Name(), opts.sort.Mode,
+		orderName(opts.sort.Descending))
+
+	var sorted *gif.GIF
+	if opts.coherent {
+		sorted = sortCoherentFrames(anim, opts)
+	} else {
+		sorted = sortFrames(anim, opts)
+	}
+
+	raw := 0
+	for _, frame := range sorted.Image {
+		raw += rawSize(frame)
+	}
+
+	fmt.Fprintf(messages, "Output format is %v (%d frames)\n", formatGif, len(sorted.Image))
+
+	return writeOutput(opts, raw, func(w io.Writer) error {
+		return gif.EncodeAll(w, sorted)
+	})
+}
+
+// sortFrames sorts each frame of an animated GIF on its own, keeping its bounds, palette, delay
+// and disposal method. Frames that only cover part of the image, or that only change some of the
+// pixels they cover, are sorted as they are, so the animation can look quite different from the
+// original.
+func sortFrames(anim *gif.GIF, opts *options) *gif.GIF {
+	sorted := *anim
+	sorted.Image = make([]*image.Paletted, len(anim.Image))
+
+	for i, frame := range anim.Image {
+		buffer, bounds := sortablecolor.SortableBufferFromImage(frame, opts.combiner)
+		buffer.Sort(bounds, opts.sort)
+
+		sortedFrame := image.NewPaletted(bounds, frame.Palette)
+		buffer.ToImage(sortedFrame)
+
+		sorted.Image[i] = sortedFrame
+	}
+
+	return &sorted
+}
+
+// sortCoherentFrames sorts each frame of an animated GIF as it's shown (drawn over the frames
+// before it), so that every frame is sorted in the same way. Pixels with equal values stay in the
+// same order, and every frame uses the same palette, so there's as little flicker as there can be.
+func sortCoherentFrames(anim *gif.GIF, opts *options) *gif.GIF {
+	frames := composite(anim)
+
+	sortOpts := opts.sort
+	sortOpts.Stable = true
+
+	for _, frame := range frames {
+		buffer, bounds := sortablecolor.SortableBufferFromImage(frame, opts.combiner)
+		buffer.Sort(bounds, sortOpts)
+		buffer.ToImage(frame)
+	}
+
+	return framesToGIF(frames, anim.Delay, anim.LoopCount, opts)
+}
+
+// framesToGIF makes an animated GIF of whole frames that are all the same size, with one palette
+// for all of them
+func framesToGIF(frames []*image.NRGBA, delay []int, loopCount int, opts *options) *gif.GIF {
+	palette := quantize.MedianCut{}.Quantize(make(color.Palette, 0, opts.gifColors), stack(frames))
+
+	var drawer draw.Drawer = draw.FloydSteinberg
+	if !opts.dither {
+		drawer = draw.Src
+	}
+
+	bounds := frames[0].Bounds()
+
+	anim := &gif.GIF{
+		Image:     make([]*image.Paletted, len(frames)),
+		Delay:     make([]int, len(frames)),
+		Disposal:  make([]byte, len(frames)),
+		LoopCount: loopCount,
+		Config: image.Config{
+			ColorModel: palette,
+			Width:      bounds.Dx(),
+			Height:     bounds.Dy(),
+		},
+	}
+
+	copy(anim.Delay, delay)
+
+	// Every frame covers the whole image, including the transparent parts, so each one has to be
+	// cleared away before the next is drawn
+	for i, frame := range frames {
+		anim.Image[i] = image.NewPaletted(bounds, palette)
+		drawer.Draw(anim.Image[i], bounds, frame, bounds.Min)
+
+		anim.Disposal[i] = gif.DisposalBackground
+	}
+
+	return anim
+}
+
+// composite gives each frame of an animated GIF as it's shown: drawn over what the frames before
+// it left behind, according to their disposal methods
+func composite(anim *gif.GIF) []*image.NRGBA {
+	bounds := image.Rect(0, 0, anim.Config.Width, anim.Config.Height)
+	if bounds.Empty() {
+		for _, frame := range anim.Image {
+			bounds = bounds.Union(frame.Bounds())
+		}
+	}
+
+	canvas := image.NewNRGBA(bounds)
+	frames := make([]*image.NRGBA, len(anim.Image))
+
+	for i, frame := range anim.Image {
+		var disposal byte
+		if i < len(anim.Disposal) {
+			disposal = anim.Disposal[i]
+		}
+
+		var previous *image.NRGBA
+		if disposal == gif.DisposalPrevious {
+			previous = cloneNRGBA(canvas)
+		}
+
+		draw.Draw(canvas, frame.Bounds(), frame, frame.Bounds().Min, draw.Over)
+		frames[i] = cloneNRGBA(canvas)
+
+		switch disposal {
+		case gif.DisposalBackground:
+			draw.Draw(canvas, frame.Bounds(), image.Transparent, image.Point{}, draw.Src)
+
+		case gif.DisposalPrevious:
+			canvas = previous
+		}
+	}
+
+	return frames
+}
+
+func cloneNRGBA(img *image.NRGBA) *image.NRGBA {
+	clone := *img
+	clone.Pix = append([]uint8(nil), img.Pix...)
+
+	return &clone
+}
+
+// stack is an image of frames of the same size, one above the other, for picking one palette for
+// all of them
+type stack []*image.NRGBA
+
+func (s stack) ColorModel() color.Model {
+	return color.NRGBAModel
+}
+
+func (s stack) Bounds() image.Rectangle {
+	size := s[0].Bounds().Size()
+
+	return image.Rect(0, 0, size.X, size.Y*len(s))
+}
+
+func (s stack) At(x, y int) color.Color {
+	bounds := s[0].Bounds()
+
+	return s[y/bounds.Dy()].At(bounds.Min.X+x, bounds.Min.Y+y%bounds.Dy())
+}
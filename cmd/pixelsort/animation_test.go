@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var animPalette = color.Palette{
+	color.Transparent,
+	color.NRGBA{R: 0xff, A: 0xff},
+	color.NRGBA{G: 0xff, A: 0xff},
+	color.NRGBA{B: 0xff, A: 0xff},
+	color.NRGBA{R: 0x80, G: 0x80, B: 0x80, A: 0xff},
+}
+
+// testAnimation makes a small animation with a frame that only covers part of the image, and a
+// frame that's disposed of by restoring what was there before
+func testAnimation() *gif.GIF {
+	full := image.NewPaletted(image.Rect(0, 0, 8, 8), animPalette)
+	for i := range full.Pix {
+		full.Pix[i] = uint8(1 + i%3)
+	}
+
+	part := image.NewPaletted(image.Rect(2, 2, 6, 6), animPalette)
+	for i := range part.Pix {
+		// Every other pixel is transparent, so the frame underneath shows through
+		part.Pix[i] = uint8(4 * (i % 2))
+	}
+
+	last := image.NewPaletted(image.Rect(4, 0, 8, 8), animPalette)
+	for i := range last.Pix {
+		last.Pix[i] = uint8(1 + i%4)
+	}
+
+	return &gif.GIF{
+		Image:     []*image.Paletted{full, part, last},
+		Delay:     []int{10, 20, 30},
+		Disposal:  []byte{gif.DisposalNone, gif.DisposalNone, gif.DisposalPrevious},
+		LoopCount: 3,
+		Config:    image.Config{ColorModel: animPalette, Width: 8, Height: 8},
+	}
+}
+
+// colorCounts counts how many pixels of each color img has
+func colorCounts(img image.Image) map[color.NRGBA]int {
+	counts := map[color.NRGBA]int{}
+
+	bounds := img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			counts[color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)]++
+		}
+	}
+
+	return counts
+}
+
+// runOnAnimation writes anim to a file in a temporary directory, sorts it with run (with the given
+// extra arguments) and gives back the decoded output
+func runOnAnimation(t *testing.T, anim *gif.GIF, args ...string) *gif.GIF {
+	dir, err := ioutil.TempDir("", "pixelsort")
+	require.NoError(t, err)
+
+	defer os.RemoveAll(dir)
+
+	input := filepath.Join(dir, "in.gif")
+
+	encoded := &bytes.Buffer{}
+	require.NoError(t, gif.EncodeAll(encoded, anim))
+	require.NoError(t, ioutil.WriteFile(input, encoded.Bytes(), 0644))
+
+	opts, err := parseOptions("pixelsort", append(args, input), &bytes.Buffer{})
+	require.NoError(t, err)
+	require.NoError(t, run(opts))
+
+	f, err := os.Open(opts.output)
+	require.NoError(t, err)
+
+	defer f.Close()
+
+	sorted, err := gif.DecodeAll(f)
+	require.NoError(t, err)
+
+	return sorted
+}
+
+func TestAnimation(t *testing.T) {
+	t.Parallel()
+
+	anim := testAnimation()
+	sorted := runOnAnimation(t, anim, "-combiner", "red")
+
+	require.Len(t, sorted.Image, len(anim.Image))
+	assert.Equal(t, anim.Delay, sorted.Delay)
+	assert.Equal(t, anim.Disposal, sorted.Disposal)
+	assert.Equal(t, anim.LoopCount, sorted.LoopCount)
+
+	for i, frame := range sorted.Image {
+		assert.Equal(t, anim.Image[i].Bounds(), frame.Bounds(), "frame %d", i)
+
+		// Sorting only moves pixels around
+		assert.Equal(t, colorCounts(anim.Image[i]), colorCounts(frame), "frame %d", i)
+	}
+}
+
+func TestAnimationCoherent(t *testing.T) {
+	t.Parallel()
+
+	anim := testAnimation()
+	shown := composite(anim)
+	sorted := runOnAnimation(t, anim, "-combiner", "red", "-coherent")
+
+	require.Len(t, sorted.Image, len(anim.Image))
+	assert.Equal(t, anim.Delay, sorted.Delay)
+	assert.Equal(t, anim.LoopCount, sorted.LoopCount)
+
+	palette := sorted.Image[0].Palette
+	for i, frame := range sorted.Image {
+		// Every frame covers the whole image, with the same palette, and is cleared away afterwards
+		assert.Equal(t, image.Rect(0, 0, 8, 8), frame.Bounds(), "frame %d", i)
+		assert.Equal(t, palette, frame.Palette, "frame %d", i)
+		assert.Equal(t, byte(gif.DisposalBackground), sorted.Disposal[i], "frame %d", i)
+
+		// The pixels are the ones that were shown for that frame, sorted
+		assert.Equal(t, colorCounts(shown[i]), colorCounts(frame), "frame %d", i)
+	}
+
+	// The second frame shows the first through its transparent pixels
+	gray := color.NRGBA{R: 0x80, G: 0x80, B: 0x80, A: 0xff}
+	assert.Equal(t, 8, colorCounts(shown[1])[gray])
+	assert.Zero(t, colorCounts(shown[1])[color.NRGBA{}])
+}
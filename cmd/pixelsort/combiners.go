@@ -0,0 +1,88 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/dcormier/go-pixelsort/colorspace"
+	"github.com/dcormier/go-pixelsort/combiner"
+	_ "github.com/dcormier/go-pixelsort/combiner/all"
+	"github.com/dcormier/go-pixelsort/combiner/cache"
+	"github.com/dcormier/go-pixelsort/combiner/distance"
+	"github.com/dcormier/go-pixelsort/combiner/expr"
+)
+
+// listCombiners writes out the names of all of the registered combiners, along with their
+// descriptions
+func listCombiners(out io.Writer) {
+	fmt.Fprintln(out, "Combiners (for -combiner):")
+
+	for _, name := range combiner.Names() {
+		cmb, err := combiner.Parse(name)
+		if err != nil {
+			if specErr, ok := err.(*combiner.SpecError); ok && specErr.Param != "" {
+				fmt.Fprintf(out, "    %-24s (requires the %q parameter)\n", name, specErr.Param)
+				continue
+			}
+
+			fmt.Fprintf(out, "    %-24s %v\n", name, err)
+			continue
+		}
+
+		fmt.Fprintf(out, "    %-24s %s\n", name, combiner.MetadataOf(cmb).Description)
+	}
+}
+
+// chooseCombiner gives the combiner to sort by, from whichever one of -combiner, -combiner-expr
+// and -reference was set
+func chooseCombiner(set map[string]bool, spec, exprSrc, reference, metric string) (combiner.Combiner, error) {
+	if (set["combiner"] && set["combiner-expr"]) ||
+		(set["combiner"] && set["reference"]) ||
+		(set["combiner-expr"] && set["reference"]) {
+		return nil, errors.New("only one of -combiner, -combiner-expr and -reference can be used")
+	}
+
+	if set["metric"] && !set["reference"] {
+		return nil, errors.New("-metric only applies to -reference")
+	}
+
+	if exprSrc != "" {
+		cmb, err := expr.Compile(exprSrc)
+		if err != nil {
+			return nil, fmt.Errorf("invalid -combiner-expr: %v", err)
+		}
+
+		return cmb, nil
+	}
+
+	if reference == "" {
+		return combiner.Parse(spec)
+	}
+
+	ref, err := colorspace.ParseHex(reference)
+	if err != nil {
+		return nil, fmt.Errorf("invalid -reference: %v", err)
+	}
+
+	m, err := distance.ParseMetric(metric)
+	if err != nil {
+		return nil, err
+	}
+
+	return distance.New(ref, m), nil
+}
+
+// makeCombiner makes the combiner for a spec, with a cache of cacheSize values if it's more than 0
+func makeCombiner(spec string, cacheSize int) (combiner.Combiner, error) {
+	cmb, err := combiner.Parse(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	if cacheSize > 0 {
+		cmb = cache.New(cmb, cacheSize)
+	}
+
+	return cmb, nil
+}
@@ -0,0 +1,477 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/image/tiff"
+
+	"github.com/dcormier/go-pixelsort/combiner"
+	"github.com/dcormier/go-pixelsort/combiner/cache"
+	"github.com/dcormier/go-pixelsort/sortablecolor"
+)
+
+// options are everything that pixelsort was asked to do
+type options struct {
+	input, output string
+
+	// format is the format to write the output in (see formats)
+	format string
+
+	// quality is the quality of JPEG output, from 1 to 100
+	quality int
+
+	// tiffCompression is the compression to use for TIFF output
+	tiffCompression tiff.CompressionType
+
+	// gifColors is the most colors to use in GIF output, and dither is whether to dither them
+	gifColors int
+	dither    bool
+
+	// coherent is whether to sort the frames of animated GIFs as they're shown, in the same way
+	coherent bool
+
+	// animate is how to animate the sort (animateTravel or animateMerge), rather than just writing
+	// the sorted image, and frames and duration are how many frames the animation has and how long
+	// it takes
+	animate  string
+	frames   int
+	duration time.Duration
+
+	// sweeps are the options to change over the frames of an animation, rather than writing just
+	// the sorted image
+	sweeps sweeps
+
+	// time is whether to sort each pixel through the frames of the input, rather than across each
+	// frame
+	time bool
+
+	// smoothing and hysteresis smooth out the intervals of video over time (see
+	// sortablecolor.TemporalMask); hysteresis is a fraction of the combiner's range
+	smoothing, hysteresis float64
+
+	// depth is the depth of the output
+	depth sortablecolor.Depth
+
+	// combinerSpec is the spec of the combiner, if it came from -combiner, and cacheSize is how
+	// many of its values to cache
+	combinerSpec string
+	cacheSize    int
+
+	// lower and upper are -lower and -upper, as fractions of the combiner's range; sort.Lower and
+	// sort.Upper are the keys that they come to
+	lower, upper float64
+
+	combiner combiner.Combiner
+	sort     sortablecolor.Options
+}
+
+// animates is whether pixelsort was asked for an animation, rather than just the sorted image
+func (opts *options) animates() bool {
+	return opts.animate != "" || len(opts.sweeps) > 0
+}
+
+const (
+	orderAscending  = "asc"
+	orderDescending = "desc"
+)
+
+// usageError is an error in how pixelsort was run, as opposed to a problem that came up while
+// running it
+type usageError struct {
+	msg string
+
+	// written is true if the error has already been written out, along with the usage
+	written bool
+}
+
+func (err *usageError) Error() string {
+	return err.msg
+}
+
+func usageErrorf(format string, args ...interface{}) error {
+	return &usageError{msg: fmt.Sprintf(format, args...)}
+}
+
+// errList is returned by parseOptions when pixelsort was asked to list the combiners, rather than
+// to sort an image
+var errList = errors.New("list the combiners")
+
+// parseOptions parses the command line arguments (without the program name). Usage and
+// -h/-help go to out. A *usageError is returned if the arguments don't make sense, and
+// flag.ErrHelp if help was asked for.
+func parseOptions(prog string, args []string, out io.Writer) (*options, error) {
+	fs := flag.NewFlagSet(prog, flag.ContinueOnError)
+	fs.SetOutput(out)
+	fs.Usage = func() { writeUsage(fs) }
+
+	var (
+		opts options
+		err  error
+	)
+
+	combinerSpec := fs.String("combiner", "perceivedoption2",
+		"the combiner to sort by, as a spec such as \"alphablend?bg=#000000\"")
+	combinerExpr := fs.String("combiner-expr", "",
+		"sort by the result of an expression such as \"0.3*r + 0.59*g + 0.11*b\" instead of -combiner")
+	reference := fs.String("reference", "",
+		"sort by distance from this hex color (such as #0050ff) instead of -combiner")
+	metric := fs.String("metric", "ciede2000",
+		"how to measure the distance from -reference: rgb, cie76 or ciede2000")
+	fs.IntVar(&opts.cacheSize, "cache", 0,
+		"remember the combined values of up to this many distinct colors, which speeds up expensive\n"+
+			"combiners on images with few colors (0 turns this off)")
+	list := fs.Bool("list", false, "list the available combiners and exit")
+
+	order := fs.String("order", orderDescending, "the order to sort in: asc or desc")
+	mode := fs.String("mode", sortablecolor.Global.String(),
+		"what to sort: global (the whole image), rows, columns or intervals (runs of pixels within\n"+
+			"each row that are between -lower and -upper)")
+	fs.Float64Var(&opts.lower, "lower", 0.25,
+		"for -mode intervals, the lowest value to sort, as a fraction of the combiner's range")
+	fs.Float64Var(&opts.upper, "upper", 0.8,
+		"for -mode intervals, the highest value to sort, as a fraction of the combiner's range")
+
+	fs.StringVar(&opts.format, "format", "",
+		"the format to write: "+strings.Join(formatNames(), ", ")+" (by default, it comes from\n"+
+			"the output file's extension, or the input's format)")
+	fs.IntVar(&opts.quality, "quality", 100, "the quality of JPEG output, from 1 to 100")
+	lossless := fs.Bool("lossless", false,
+		"write PNG instead of JPEG; JPEG halves the resolution of color (even at -quality 100),\n"+
+			"which smears sorted streaks")
+	compression := fs.String("compression", "deflate", "the compression for TIFF output: none or deflate")
+	fs.IntVar(&opts.gifColors, "colors", 256, "the most colors to use in GIF output, from 2 to 256")
+	fs.BoolVar(&opts.dither, "dither", true, "dither GIF output, when the image has more colors than -colors")
+	fs.BoolVar(&opts.coherent, "coherent", false,
+		"sort the frames of animated GIFs as they're shown (rather than just the parts of the image\n"+
+			"that each one changes), keeping equal pixels in order and using one palette for all of them,\n"+
+			"which reduces flicker")
+	fs.StringVar(&opts.animate, "animate", "",
+		"write an animation of the sort rather than just the sorted image: travel (each pixel moves to\n"+
+			"where it's sorted to) or merge (the passes of a merge sort); it's an animated GIF for gif\n"+
+			"output, or numbered files (such as out_0001.png) for any other format")
+	fs.Var(&opts.sweeps, "sweep",
+		"write an animation that changes an option from keyframe to keyframe, such as lower=0.2:0.8\n"+
+			"or origin=0:180:360 (for -combiner hue); the option can be lower, upper, or any parameter\n"+
+			"of -combiner, and -sweep can be given more than once; it's written like -animate")
+	fs.IntVar(&opts.frames, "frames", 30, "the number of frames for -animate or -sweep")
+	fs.DurationVar(&opts.duration, "duration", 3*time.Second, "how long the animation for -animate or -sweep takes, for gif output")
+	fs.BoolVar(&opts.time, "time", false,
+		"sort each pixel through time, across the frames of an animated GIF, a y4m video, or a sequence\n"+
+			"of images given as a pattern such as \"frames/*.png\" (with -mode global or intervals)")
+	fs.Float64Var(&opts.smoothing, "smooth", 0,
+		"for -mode intervals on video, how much of each pixel's value from the frames before it to\n"+
+			"keep when finding intervals, from 0 up to (but not including) 1, so they don't jitter")
+	fs.Float64Var(&opts.hysteresis, "hysteresis", 0,
+		"for -mode intervals on video, how far past -lower and -upper (as a fraction of the\n"+
+			"combiner's range) a pixel that was in an interval in the frame before can go and stay in one")
+	depth := fs.String("depth", sortablecolor.SameDepth.String(),
+		"the bits per channel of the output: 8, 16, or auto to match the input (grayscale input stays\n"+
+			"grayscale either way)")
+	fs.StringVar(&opts.output, "output", "",
+		"the file to write to (by default, the input's name with \"_sorted\" added); - writes to\n"+
+			"stdout, in the input's format (y4m for stdin) unless -format says otherwise")
+	fs.StringVar(&opts.output, "o", "", "shorthand for -output")
+
+	if err = fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return nil, err
+		}
+
+		// The flag package has already written out what was wrong, along with the usage
+		return nil, &usageError{msg: err.Error(), written: true}
+	}
+
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	if *list {
+		return nil, errList
+	}
+
+	switch fs.NArg() {
+	case 0:
+		return nil, usageErrorf("no input file was given")
+
+	case 1:
+		opts.input = fs.Arg(0)
+
+	case 2:
+		if set["o"] || set["output"] {
+			return nil, usageErrorf("the output file can be given with -output or as an argument, but not both")
+		}
+
+		opts.input, opts.output = fs.Arg(0), fs.Arg(1)
+
+	default:
+		return nil, usageErrorf("too many arguments: %s", strings.Join(fs.Args()[2:], " "))
+	}
+
+	if set["o"] && set["output"] {
+		return nil, usageErrorf("only one of -o and -output can be used")
+	}
+
+	if opts.combiner, err = chooseCombiner(set, *combinerSpec, *combinerExpr, *reference, *metric); err != nil {
+		return nil, &usageError{msg: err.Error()}
+	}
+
+	if !set["combiner-expr"] && !set["reference"] {
+		opts.combinerSpec = *combinerSpec
+	}
+
+	if opts.cacheSize < 0 {
+		return nil, usageErrorf("-cache can't be negative")
+	}
+
+	if opts.cacheSize > 0 {
+		opts.combiner = cache.New(opts.combiner, opts.cacheSize)
+	}
+
+	switch *order {
+	case orderAscending:
+	case orderDescending:
+		opts.sort.Descending = true
+	default:
+		return nil, usageErrorf("unknown -order %q (expected %s or %s)", *order, orderAscending, orderDescending)
+	}
+
+	if opts.sort.Mode, err = sortablecolor.ParseMode(*mode); err != nil {
+		return nil, &usageError{msg: err.Error()}
+	}
+
+	if opts.sort.Mode != sortablecolor.Intervals && (set["lower"] || set["upper"]) {
+		return nil, usageErrorf("-lower and -upper only apply to -mode %s", sortablecolor.Intervals)
+	}
+
+	if opts.lower < 0 || opts.upper > 1 || opts.lower > opts.upper {
+		return nil, usageErrorf("-lower and -upper must be within [0, 1], with -lower no higher than -upper")
+	}
+
+	md := combiner.MetadataOf(opts.combiner)
+	opts.sort.Lower, opts.sort.Upper = md.Key(opts.lower), md.Key(opts.upper)
+
+	if opts.quality < 1 || opts.quality > 100 {
+		return nil, usageErrorf("-quality must be from 1 to 100")
+	}
+
+	// The extension of the output file, if one was given with it
+	outputExt := filepath.Ext(opts.output)
+
+	if err = chooseOutput(&opts, set["format"]); err != nil {
+		return nil, err
+	}
+
+	if *lossless && opts.format == formatJpeg {
+		if set["format"] {
+			return nil, usageErrorf("-lossless can't be used with -format %s", formatJpeg)
+		}
+
+		if outputExt != "" {
+			return nil, usageErrorf("-lossless can't be used with the %s output file %s", formatJpeg, opts.output)
+		}
+
+		if opts.output != "-" {
+			ext := filepath.Ext(opts.output)
+			opts.output = opts.output[:len(opts.output)-len(ext)] + formats[formatPng].exts[0]
+		}
+
+		opts.format = formatPng
+	}
+
+	if opts.depth, err = sortablecolor.ParseDepth(*depth); err != nil {
+		return nil, &usageError{msg: err.Error()}
+	}
+
+	if opts.sort.Mode != sortablecolor.Intervals && (set["smooth"] || set["hysteresis"]) {
+		return nil, usageErrorf("-smooth and -hysteresis only apply to -mode %s", sortablecolor.Intervals)
+	}
+
+	if opts.smoothing < 0 || opts.smoothing >= 1 {
+		return nil, usageErrorf("-smooth must be at least 0, and less than 1")
+	}
+
+	if opts.hysteresis < 0 || opts.hysteresis > 1 {
+		return nil, usageErrorf("-hysteresis must be within [0, 1]")
+	}
+
+	if err = checkTime(&opts, set); err != nil {
+		return nil, err
+	}
+
+	if opts.tiffCompression, err = parseTIFFCompression(*compression); err != nil {
+		return nil, &usageError{msg: err.Error()}
+	}
+
+	if opts.gifColors < 2 || opts.gifColors > 256 {
+		return nil, usageErrorf("-colors must be from 2 to 256")
+	}
+
+	switch opts.animate {
+	case "", animateTravel, animateMerge:
+	default:
+		return nil, usageErrorf("unknown -animate %q (expected %s or %s)", opts.animate, animateTravel, animateMerge)
+	}
+
+	if set["animate"] && set["sweep"] {
+		return nil, usageErrorf("only one of -animate and -sweep can be used")
+	}
+
+	if opts.animates() {
+		if opts.output == "-" && opts.format != formatGif {
+			return nil, usageErrorf("an animation can only be written to stdout as a gif, not as numbered %s files",
+				opts.format)
+		}
+
+		if opts.frames < 2 {
+			return nil, usageErrorf("-frames must be at least 2")
+		}
+
+		if opts.duration <= 0 {
+			return nil, usageErrorf("-duration must be more than zero")
+		}
+
+		if opts.coherent {
+			return nil, usageErrorf("-coherent can't be used with -animate or -sweep")
+		}
+	} else if set["frames"] || set["duration"] {
+		return nil, usageErrorf("-frames and -duration only apply with -animate or -sweep")
+	}
+
+	for _, sw := range opts.sweeps {
+		if set[sw.name] && !sw.isCombinerParam() {
+			return nil, usageErrorf("-%s can't be used with -sweep %s", sw.name, sw.name)
+		}
+	}
+
+	if err = checkSweeps(&opts); err != nil {
+		return nil, err
+	}
+
+	for _, only := range []struct {
+		flag, format string
+	}{
+		{"quality", formatJpeg},
+		{"compression", formatTiff},
+		{"colors", formatGif},
+		{"dither", formatGif},
+		{"coherent", formatGif},
+		{"duration", formatGif},
+		{"smooth", formatY4M},
+		{"hysteresis", formatY4M},
+	} {
+		if set[only.flag] && opts.format != only.format {
+			return nil, usageErrorf("-%s only applies to %s output, not %s", only.flag, only.format, opts.format)
+		}
+	}
+
+	return &opts, nil
+}
+
+// checkTime makes sure that -time can be used with the rest of the options, and that a sequence
+// of images is only given with it
+func checkTime(opts *options, set map[string]bool) error {
+	if !opts.time {
+		if isSequence(opts.input) {
+			return usageErrorf("-time is needed to sort a sequence of images such as %s", opts.input)
+		}
+
+		return nil
+	}
+
+	switch {
+	case opts.sort.Mode != sortablecolor.Global && opts.sort.Mode != sortablecolor.Intervals:
+		return usageErrorf("-time only works with -mode %s or %s", sortablecolor.Global, sortablecolor.Intervals)
+
+	case opts.animates():
+		return usageErrorf("-time can't be used with -animate or -sweep")
+
+	case opts.coherent:
+		return usageErrorf("-coherent can't be used with -time")
+
+	case set["smooth"] || set["hysteresis"]:
+		return usageErrorf("-smooth and -hysteresis can't be used with -time")
+	}
+
+	return nil
+}
+
+// chooseOutput fills in the output file and format, if they weren't given. The format comes from
+// the output file's extension, if it has one, and the input's extension otherwise. Video streamed
+// from stdin is written to stdout as y4m.
+func chooseOutput(opts *options, formatSet bool) error {
+	if formatSet {
+		if _, ok := formats[opts.format]; !ok {
+			return usageErrorf("unknown -format %q (expected one of %s)", opts.format,
+				strings.Join(formatNames(), ", "))
+		}
+	}
+
+	if opts.output == "" && opts.input == "-" {
+		opts.output = "-"
+	}
+
+	if opts.output == "-" {
+		if !formatSet {
+			opts.format = defaultFormatFor(opts.input)
+			if opts.input == "-" {
+				opts.format = formatY4M
+			}
+		}
+
+		return nil
+	}
+
+	if opts.output == "" && isSequence(opts.input) {
+		return usageErrorf("an output file is needed for a sequence of images such as %s", opts.input)
+	}
+
+	if opts.output == "" {
+		ext := filepath.Ext(opts.input)
+		opts.output = opts.input[:len(opts.input)-len(ext)] + "_sorted"
+	}
+
+	if ext := filepath.Ext(opts.output); ext != "" {
+		extFormat, ok := formatForExt(ext)
+
+		switch {
+		case !ok && !formatSet:
+			return usageErrorf("can't tell which format to write %s in from its extension; use -format",
+				opts.output)
+
+		case ok && formatSet && extFormat != opts.format:
+			return usageErrorf("-format %s doesn't match the output file %s", opts.format, opts.output)
+
+		case ok:
+			opts.format = extFormat
+		}
+
+		return nil
+	}
+
+	if !formatSet {
+		opts.format = defaultFormatFor(opts.input)
+	}
+
+	opts.output += formats[opts.format].exts[0]
+
+	return nil
+}
+
+// writeUsage writes out how to use pixelsort, including the combiners that can be used
+func writeUsage(fs *flag.FlagSet) {
+	out := fs.Output()
+
+	fmt.Fprintf(out, "Usage: %s [flags] <input> [output]\n\n", fs.Name())
+	fmt.Fprintln(out, "Sorts the pixels of an image.")
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Flags:")
+	fs.PrintDefaults()
+	fmt.Fprintln(out)
+
+	listCombiners(out)
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"golang.org/x/image/tiff"
+
+	"github.com/dcormier/go-pixelsort/combiner"
+	"github.com/dcormier/go-pixelsort/sortablecolor"
+)
+
+func TestParseOptionsOutput(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range []struct {
+		args           []string
+		output, format string
+	}{
+		{[]string{"pic.jpg"}, "pic_sorted.jpg", formatJpeg},
+		{[]string{"pic.gif"}, "pic_sorted.gif", formatGif},
+		{[]string{"pic.TIF"}, "pic_sorted.tiff", formatTiff},
+		{[]string{"pic.bmp"}, "pic_sorted.png", formatPng},
+		{[]string{"dir.v2/pic"}, "dir.v2/pic_sorted.png", formatPng},
+		{[]string{"-format", "png", "pic.jpg"}, "pic_sorted.png", formatPng},
+
+		// The output is used as given
+		{[]string{"in/a-long-name.jpg", "out/b.png"}, "out/b.png", formatPng},
+		{[]string{"in/a.png", "out/a-long-name.JPEG"}, "out/a-long-name.JPEG", formatJpeg},
+		{[]string{"-o", "out/b", "a.png"}, "out/b.png", formatPng},
+		{[]string{"-output", "out/b", "-format", "jpeg", "a.png"}, "out/b.jpg", formatJpeg},
+		{[]string{"a.png", "b"}, "b.png", formatPng},
+		{[]string{"a.png", "b.tif"}, "b.tif", formatTiff},
+		{[]string{"-format", "gif", "a.png", "b"}, "b.gif", formatGif},
+
+		// -lossless turns JPEG into PNG
+		{[]string{"-lossless", "pic.jpg"}, "pic_sorted.png", formatPng},
+		{[]string{"-lossless", "pic.jpg", "out"}, "out.png", formatPng},
+		{[]string{"-lossless", "pic.jpg", "out.tiff"}, "out.tiff", formatTiff},
+
+		// Video, and streaming it through stdin and stdout
+		{[]string{"clip.y4m"}, "clip_sorted.y4m", formatY4M},
+		{[]string{"-"}, "-", formatY4M},
+		{[]string{"clip.y4m", "-"}, "-", formatY4M},
+		{[]string{"-o", "-", "a.png"}, "-", formatPng},
+		{[]string{"pic.jpg", "-"}, "-", formatJpeg},
+		{[]string{"-lossless", "pic.jpg", "-"}, "-", formatPng},
+		{[]string{"-format", "png", "-", "-"}, "-", formatPng},
+		{[]string{"-", "out.png"}, "out.png", formatPng},
+	} {
+		opts, err := parseOptions("pixelsort", test.args, &bytes.Buffer{})
+		require.NoError(t, err, "%q", test.args)
+
+		assert.Equal(t, filepath.FromSlash(test.output), filepath.FromSlash(opts.output), "%q", test.args)
+		assert.Equal(t, test.format, opts.format, "%q", test.args)
+	}
+}
+
+func TestParseOptionsSort(t *testing.T) {
+	t.Parallel()
+
+	opts, err := parseOptions("pixelsort", []string{"pic.png"}, &bytes.Buffer{})
+	require.NoError(t, err)
+	assert.Equal(t, "perceived (option 2)", opts.combiner.Name())
+	assert.Equal(t, sortablecolor.Global, opts.sort.Mode)
+	assert.True(t, opts.sort.Descending)
+	assert.Equal(t, 100, opts.quality)
+
+	opts, err = parseOptions("pixelsort",
+		[]string{"-combiner", "red", "-order", "asc", "-mode", "intervals", "-lower", "0", "-upper", "0.5", "pic.png"},
+		&bytes.Buffer{})
+	require.NoError(t, err)
+	assert.Equal(t, "red", opts.combiner.Name())
+	assert.False(t, opts.sort.Descending)
+	assert.Equal(t, sortablecolor.Options{Mode: sortablecolor.Intervals, Lower: 0, Upper: 0x7fff}, opts.sort)
+
+	opts, err = parseOptions("pixelsort", []string{"-reference", "#ff0000", "-metric", "rgb", "pic.png"},
+		&bytes.Buffer{})
+	require.NoError(t, err)
+	assert.Equal(t, combiner.MustParse("distance?ref=#ff0000&metric=rgb").Name(), opts.combiner.Name())
+
+	opts, err = parseOptions("pixelsort", []string{"-cache", "10", "-quality", "90", "pic.jpg"}, &bytes.Buffer{})
+	require.NoError(t, err)
+	assert.Equal(t, 90, opts.quality)
+
+	opts, err = parseOptions("pixelsort", []string{"-compression", "none", "pic.tiff"}, &bytes.Buffer{})
+	require.NoError(t, err)
+	assert.Equal(t, tiff.Uncompressed, opts.tiffCompression)
+
+	opts, err = parseOptions("pixelsort", []string{"-colors", "16", "-dither=false", "pic.gif"}, &bytes.Buffer{})
+	require.NoError(t, err)
+	assert.Equal(t, 16, opts.gifColors)
+	assert.False(t, opts.dither)
+}
+
+func TestParseOptionsErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range []struct {
+		args []string
+		msg  string
+	}{
+		{nil, "no input file was given"},
+		{[]string{"a.png", "b.png", "c.png"}, "too many arguments: c.png"},
+		{[]string{"-o", "b.png", "a.png", "c.png"},
+			"the output file can be given with -output or as an argument, but not both"},
+		{[]string{"-o", "b.png", "-output", "c.png", "a.png"}, "only one of -o and -output can be used"},
+		{[]string{"-combiner", "red", "-reference", "#fff", "a.png"},
+			"only one of -combiner, -combiner-expr and -reference can be used"},
+		{[]string{"-metric", "rgb", "a.png"}, "-metric only applies to -reference"},
+		{[]string{"-combiner", "nope", "a.png"}, `unknown combiner "nope"`},
+		{[]string{"-combiner-expr", "r +", "a.png"},
+			`invalid -combiner-expr: column 4: expected a number, variable, function or "(", found end of expression`},
+		{[]string{"-cache", "-1", "a.png"}, "-cache can't be negative"},
+		{[]string{"-order", "up", "a.png"}, `unknown -order "up" (expected asc or desc)`},
+		{[]string{"-mode", "diagonal", "a.png"},
+			`unknown sort mode "diagonal" (expected one of global, rows, columns, intervals)`},
+		{[]string{"-lower", "0.1", "a.png"}, "-lower and -upper only apply to -mode intervals"},
+		{[]string{"-mode", "intervals", "-lower", "0.9", "-upper", "0.1", "a.png"},
+			"-lower and -upper must be within [0, 1], with -lower no higher than -upper"},
+		{[]string{"-quality", "0", "a.jpg"}, "-quality must be from 1 to 100"},
+		{[]string{"-quality", "90", "a.png"}, "-quality only applies to jpeg output, not png"},
+		{[]string{"-quality", "90", "-lossless", "a.jpg"}, "-quality only applies to jpeg output, not png"},
+		{[]string{"-format", "jpeg", "-lossless", "a.png"}, "-lossless can't be used with -format jpeg"},
+		{[]string{"-lossless", "pic.jpg", "out.JPEG"}, "-lossless can't be used with the jpeg output file out.JPEG"},
+		{[]string{"-format", "bmp", "a.png"}, `unknown -format "bmp" (expected one of gif, jpeg, pam, pbm, pgm, png, ppm, tiff, y4m)`},
+		{[]string{"-compression", "lzw", "a.tiff"}, `unknown TIFF compression "lzw" (expected none or deflate)`},
+		{[]string{"-compression", "none", "a.png"}, "-compression only applies to tiff output, not png"},
+		{[]string{"-colors", "300", "a.gif"}, "-colors must be from 2 to 256"},
+		{[]string{"-dither=false", "a.jpg"}, "-dither only applies to gif output, not jpeg"},
+		{[]string{"-coherent", "a.gif", "b.png"}, "-coherent only applies to gif output, not png"},
+		{[]string{"-animate", "spin", "a.png"}, `unknown -animate "spin" (expected travel or merge)`},
+		{[]string{"-frames", "10", "a.png"}, "-frames and -duration only apply with -animate or -sweep"},
+		{[]string{"-animate", "merge", "-frames", "1", "a.png"}, "-frames must be at least 2"},
+		{[]string{"-animate", "travel", "-duration", "0s", "a.png"}, "-duration must be more than zero"},
+		{[]string{"-animate", "travel", "-duration", "2s", "a.png"}, "-duration only applies to gif output, not png"},
+		{[]string{"-animate", "travel", "-coherent", "a.gif"}, "-coherent can't be used with -animate or -sweep"},
+		{[]string{"-sweep", "lower", "a.png"}, `invalid value "lower" for flag -sweep: expected name=from:to, found "lower"`},
+		{[]string{"-sweep", "lower=0.2:0.8", "a.png"}, "-sweep lower only applies to -mode intervals"},
+		{[]string{"-mode", "intervals", "-sweep", "upper=0.5:1.5", "a.png"}, "-sweep upper must stay within [0, 1]"},
+		{[]string{"-mode", "intervals", "-lower", "0.1", "-sweep", "lower=0:1", "a.png"},
+			"-lower can't be used with -sweep lower"},
+		{[]string{"-combiner", "hue", "-sweep", "origin=0:400", "a.png"},
+			`-sweep origin: combiner spec "hue?origin=400": parameter "origin": 400 is out of range [-360, 360]`},
+		{[]string{"-combiner", "localcontrast", "-sweep", "radius=1:2.5", "a.png"},
+			"-sweep radius: 2.5 isn't a whole number"},
+		{[]string{"-combiner", "hue", "-sweep", "angle=0:90", "a.png"},
+			`-sweep angle: combiner spec "hue?angle=0": unknown parameter(s) angle (expected origin)`},
+		{[]string{"-reference", "#fff", "-sweep", "origin=0:90", "a.png"},
+			"-sweep origin is a parameter of -combiner, so it can't be used with -combiner-expr or -reference"},
+		{[]string{"-animate", "merge", "-sweep", "origin=0:90", "a.png"}, "only one of -animate and -sweep can be used"},
+		{[]string{"-smooth", "0.5", "a.y4m"}, "-smooth and -hysteresis only apply to -mode intervals"},
+		{[]string{"-mode", "intervals", "-smooth", "1", "a.y4m"}, "-smooth must be at least 0, and less than 1"},
+		{[]string{"-mode", "intervals", "-hysteresis", "-0.1", "a.y4m"}, "-hysteresis must be within [0, 1]"},
+		{[]string{"-mode", "intervals", "-hysteresis", "0.1", "a.png"}, "-hysteresis only applies to y4m output, not png"},
+		{[]string{"-time", "-mode", "rows", "a.gif"}, "-time only works with -mode global or intervals"},
+		{[]string{"-time", "-animate", "merge", "a.gif"}, "-time can't be used with -animate or -sweep"},
+		{[]string{"-time", "-coherent", "a.gif"}, "-coherent can't be used with -time"},
+		{[]string{"-time", "-mode", "intervals", "-smooth", "0.5", "a.y4m"},
+			"-smooth and -hysteresis can't be used with -time"},
+		{[]string{"frames/*.png", "out.png"}, "-time is needed to sort a sequence of images such as frames/*.png"},
+		{[]string{"-time", "frames/*.png"}, "an output file is needed for a sequence of images such as frames/*.png"},
+		{[]string{"-animate", "merge", "a.png", "-"},
+			"an animation can only be written to stdout as a gif, not as numbered png files"},
+		{[]string{"-format", "png", "a.png", "b.jpg"}, "-format png doesn't match the output file b.jpg"},
+		{[]string{"a.png", "b.webp"}, "can't tell which format to write b.webp in from its extension; use -format"},
+	} {
+		_, err := parseOptions("pixelsort", test.args, &bytes.Buffer{})
+		require.Error(t, err, "%q", test.args)
+		require.IsType(t, &usageError{}, err, "%q", test.args)
+		assert.Contains(t, err.Error(), test.msg, "%q", test.args)
+	}
+}
+
+func TestParseOptionsUsage(t *testing.T) {
+	t.Parallel()
+
+	out := &bytes.Buffer{}
+	_, err := parseOptions("pixelsort", []string{"-h"}, out)
+	assert.Equal(t, flag.ErrHelp, err)
+	assert.Contains(t, out.String(), "Usage: pixelsort [flags] <input> [output]")
+	assert.Contains(t, out.String(), "-order")
+	assert.Contains(t, out.String(), "Combiners (for -combiner):")
+	assert.Contains(t, out.String(), "perceivedoption2")
+
+	out.Reset()
+	_, err = parseOptions("pixelsort", []string{"-bogus", "a.png"}, out)
+	require.IsType(t, &usageError{}, err)
+	assert.True(t, err.(*usageError).written)
+	assert.Contains(t, out.String(), "flag provided but not defined: -bogus")
+
+	_, err = parseOptions("pixelsort", []string{"-list"}, out)
+	assert.Equal(t, errList, err)
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/image/tiff"
+
+	"github.com/dcormier/go-pixelsort/netpbm"
+	"github.com/dcormier/go-pixelsort/quantize"
+	"github.com/dcormier/go-pixelsort/y4m"
+)
+
+const (
+	formatJpeg = "jpeg"
+	formatPng  = "png"
+	formatGif  = "gif"
+	formatTiff = "tiff"
+	formatY4M  = "y4m"
+	formatPBM  = "pbm"
+	formatPGM  = "pgm"
+	formatPPM  = "ppm"
+	formatPAM  = "pam"
+)
+
+// tiffCompressions are the names of the TIFF compression types that can be used
+var tiffCompressions = map[string]tiff.CompressionType{
+	"none":    tiff.Uncompressed,
+	"deflate": tiff.Deflate,
+}
+
+// parseTIFFCompression gives the TIFF compression type with the given name
+func parseTIFFCompression(name string) (tiff.CompressionType, error) {
+	if c, ok := tiffCompressions[strings.ToLower(name)]; ok {
+		return c, nil
+	}
+
+	return 0, fmt.Errorf("unknown TIFF compression %q (expected none or deflate)", name)
+}
+
+// format is an image format that pixelsort can write
+type format struct {
+	// exts are the file extensions for the format; the first is the one given to output files
+	exts []string
+
+	encode func(w io.Writer, img image.Image, opts *options) error
+}
+
+var formats = map[string]format{
+	formatPng: {
+		exts: []string{".png"},
+		encode: func(w io.Writer, img image.Image, _ *options) error {
+			return png.Encode(w, img)
+		},
+	},
+	formatJpeg: {
+		exts: []string{".jpg", ".jpeg"},
+		encode: func(w io.Writer, img image.Image, opts *options) error {
+			return jpeg.Encode(w, img, &jpeg.Options{Quality: opts.quality})
+		},
+	},
+	formatGif: {
+		exts: []string{".gif"},
+		encode: func(w io.Writer, img image.Image, opts *options) error {
+			gifOpts := &gif.Options{
+				NumColors: opts.gifColors,
+				Quantizer: quantize.MedianCut{},
+				Drawer:    draw.FloydSteinberg,
+			}
+
+			if !opts.dither {
+				gifOpts.Drawer = draw.Src
+			}
+
+			return gif.Encode(w, img, gifOpts)
+		},
+	},
+	formatTiff: {
+		exts: []string{".tiff", ".tif"},
+		encode: func(w io.Writer, img image.Image, opts *options) error {
+			return tiff.Encode(w, img, &tiff.Options{Compression: opts.tiffCompression})
+		},
+	},
+	formatY4M: {
+		exts: []string{".y4m"},
+		encode: func(w io.Writer, img image.Image, _ *options) error {
+			// A single image is a video with one frame, with all of its color
+			header := y4m.Header{Width: img.Bounds().Dx(), Height: img.Bounds().Dy(), Colorspace: y4m.C444}
+			if img.ColorModel() == color.GrayModel || img.ColorModel() == color.Gray16Model {
+				header.Colorspace = y4m.Mono
+			}
+
+			yw, err := y4m.NewWriter(w, header)
+			if err != nil {
+				return err
+			}
+
+			return yw.WriteFrame(img)
+		},
+	},
+	formatPBM: netpbmFormat(".pbm", netpbm.PBM),
+	formatPGM: netpbmFormat(".pgm", netpbm.PGM),
+	formatPPM: netpbmFormat(".ppm", netpbm.PPM),
+	formatPAM: netpbmFormat(".pam", netpbm.PAM),
+}
+
+// netpbmFormat gives the format for one of the Netpbm formats, which keep 16 bits of each channel
+// when the image has them
+func netpbmFormat(ext string, f netpbm.Format) format {
+	return format{
+		exts: []string{ext},
+		encode: func(w io.Writer, img image.Image, _ *options) error {
+			return netpbm.Encode(w, img, &netpbm.Options{Format: f})
+		},
+	}
+}
+
+// formatNames gives the names of all of the formats, sorted
+func formatNames() []string {
+	names := make([]string, 0, len(formats))
+	for name := range formats {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// formatForExt gives the name of the format of files with the given extension
+func formatForExt(ext string) (string, bool) {
+	for name, f := range formats {
+		for _, fExt := range f.exts {
+			if strings.EqualFold(ext, fExt) {
+				return name, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// defaultFormatFor gives the format to write when sorting input, if nothing else says which to use.
+// That's the format of the input (judging by its extension), if it can be written, or PNG.
+func defaultFormatFor(input string) string {
+	if name, ok := formatForExt(filepath.Ext(input)); ok {
+		return name
+	}
+
+	return formatPng
+}
+
+// rawSize gives the number of bytes that the pixels of img take up in memory
+func rawSize(img image.Image) int {
+	switch img := img.(type) {
+	case *image.Gray:
+		return len(img.Pix)
+	case *image.Gray16:
+		return len(img.Pix)
+	case *image.NRGBA:
+		return len(img.Pix)
+	case *image.NRGBA64:
+		return len(img.Pix)
+	case *image.Paletted:
+		return len(img.Pix)
+	}
+
+	// Assume 16-bit RGBA
+	return img.Bounds().Dx() * img.Bounds().Dy() * 8
+}
+
+// formatSize formats a number of bytes for people to read, such as "1.5 MiB"
+func formatSize(n int) string {
+	const unit = 1024
+
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+
+	size, prefix := float64(n)/unit, 0
+	for size >= unit && prefix < len("KMGT")-1 {
+		size /= unit
+		prefix++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", size, "KMGT"[prefix])
+}
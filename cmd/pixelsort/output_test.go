@@ -0,0 +1,226 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/image/tiff"
+)
+
+// roundTrip encodes img in the given format and decodes it again
+func roundTrip(t *testing.T, img image.Image, format string, opts *options) image.Image {
+	buf := &bytes.Buffer{}
+	require.NoError(t, formats[format].encode(buf, img, opts))
+
+	decoded, decodedFormat, err := image.Decode(buf)
+	require.NoError(t, err)
+	require.Equal(t, format, decodedFormat)
+	require.Equal(t, img.Bounds().Size(), decoded.Bounds().Size())
+
+	return decoded
+}
+
+// stripes makes an image with a few colors, including translucent ones
+func stripes() *image.NRGBA {
+	colors := []color.NRGBA{
+		{R: 0xff, A: 0xff},
+		{G: 0x80, B: 0x40, A: 0xff},
+		{R: 0x20, G: 0x20, B: 0x20, A: 0xff},
+		{R: 0xff, G: 0xff, B: 0xff, A: 0xff},
+	}
+
+	img := image.NewNRGBA(image.Rect(0, 0, 16, 16))
+	for y := 0; y < 16; y++ {
+		for x := 0; x < 16; x++ {
+			img.SetNRGBA(x, y, colors[(x/4)%len(colors)])
+		}
+	}
+
+	return img
+}
+
+// assertSameColors checks that the pixels of two images are within delta of each other (out of
+// 0xffff)
+func assertSameColors(t *testing.T, expected, actual image.Image, delta float64) {
+	eb, ab := expected.Bounds(), actual.Bounds()
+
+	for y := 0; y < eb.Dy(); y++ {
+		for x := 0; x < eb.Dx(); x++ {
+			er, eg, ebl, ea := expected.At(eb.Min.X+x, eb.Min.Y+y).RGBA()
+			ar, ag, abl, aa := actual.At(ab.Min.X+x, ab.Min.Y+y).RGBA()
+
+			if !assert.InDeltaSlice(t, []float64{float64(er), float64(eg), float64(ebl), float64(ea)},
+				[]float64{float64(ar), float64(ag), float64(abl), float64(aa)}, delta, "at %d,%d", x, y) {
+				return
+			}
+		}
+	}
+}
+
+// converted gives a copy of img with its colors converted to model
+func converted(img image.Image, model color.Model) image.Image {
+	bounds := img.Bounds()
+
+	out := image.NewNRGBA64(bounds)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			out.Set(x, y, model.Convert(img.At(x, y)))
+		}
+	}
+
+	return out
+}
+
+// bitmapModel converts colors to black or white, as PBM images do
+var bitmapModel = color.ModelFunc(func(c color.Color) color.Color {
+	if color.GrayModel.Convert(c).(color.Gray).Y < 0x80 {
+		return color.Black
+	}
+
+	return color.White
+})
+
+func TestRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	img := stripes()
+	opts := &options{quality: 100, gifColors: 256, dither: true, tiffCompression: tiff.Deflate}
+
+	for _, format := range formatNames() {
+		format := format
+
+		t.Run(format, func(t *testing.T) {
+			t.Parallel()
+
+			expected, delta := image.Image(img), 0.0
+			switch format {
+			case formatJpeg:
+				delta = 0x0800
+			case formatY4M:
+				// Converting to YCbCr and back is off by a little
+				delta = 0x0300
+			case formatPGM:
+				// Only the gray of each pixel is kept
+				expected = converted(img, color.GrayModel)
+			case formatPBM:
+				expected = converted(img, bitmapModel)
+			}
+
+			assertSameColors(t, expected, roundTrip(t, img, format, opts), delta)
+		})
+	}
+}
+
+func TestRoundTripTIFF(t *testing.T) {
+	t.Parallel()
+
+	// A 16-bit image, to check that no precision is lost
+	img := image.NewNRGBA64(image.Rect(0, 0, 8, 8))
+	for i := 0; i < 64; i++ {
+		img.SetNRGBA64(i%8, i/8, color.NRGBA64{R: uint16(i * 1001), G: 0x1234, B: uint16(0xffff - i), A: 0xffff})
+	}
+
+	for _, compression := range tiffCompressions {
+		assertSameColors(t, img, roundTrip(t, img, formatTiff, &options{tiffCompression: compression}), 0)
+	}
+
+	uncompressed, deflated := &bytes.Buffer{}, &bytes.Buffer{}
+	require.NoError(t, formats[formatTiff].encode(uncompressed, stripes(), &options{tiffCompression: tiff.Uncompressed}))
+	require.NoError(t, formats[formatTiff].encode(deflated, stripes(), &options{tiffCompression: tiff.Deflate}))
+	assert.True(t, deflated.Len() < uncompressed.Len())
+}
+
+func TestRunNetpbm(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "pixelsort")
+	require.NoError(t, err)
+
+	defer os.RemoveAll(dir)
+
+	// A translucent 16-bit image, which only PAM can hold all of
+	img := image.NewNRGBA64(image.Rect(0, 0, 8, 8))
+	for i := 0; i < 64; i++ {
+		img.SetNRGBA64(i%8, i/8, color.NRGBA64{R: uint16(i * 1001), G: 0x1234, B: 0xfedc, A: uint16(0xffff - i*99)})
+	}
+
+	input := filepath.Join(dir, "in.pam")
+
+	encoded := &bytes.Buffer{}
+	require.NoError(t, formats[formatPAM].encode(encoded, img, nil))
+	require.NoError(t, ioutil.WriteFile(input, encoded.Bytes(), 0644))
+
+	opts, err := parseOptions("pixelsort", []string{"-combiner", "red", input}, &bytes.Buffer{})
+	require.NoError(t, err)
+	assert.Equal(t, formatPAM, opts.format)
+	require.NoError(t, run(opts))
+
+	sorted := imageFromFile(t, opts.output)
+	assert.Equal(t, color.NRGBA64Model, sorted.ColorModel())
+
+	// Sorting only moves pixels around
+	counts := func(img image.Image) map[color.Color]int {
+		counts := map[color.Color]int{}
+		for y := 0; y < 8; y++ {
+			for x := 0; x < 8; x++ {
+				counts[img.At(x, y)]++
+			}
+		}
+
+		return counts
+	}
+
+	assert.Equal(t, counts(img), counts(sorted))
+}
+
+func TestRoundTripGIF(t *testing.T) {
+	t.Parallel()
+
+	// A gradient has more colors than a GIF can hold, so they have to be quantized
+	img := image.NewNRGBA(image.Rect(0, 0, 64, 64))
+	for y := 0; y < 64; y++ {
+		for x := 0; x < 64; x++ {
+			img.SetNRGBA(x, y, color.NRGBA{R: uint8(x * 4), G: uint8(y * 4), B: 0x80, A: 0xff})
+		}
+	}
+
+	for _, dither := range []bool{true, false} {
+		decoded := roundTrip(t, img, formatGif, &options{gifColors: 16, dither: dither})
+
+		paletted, ok := decoded.(*image.Paletted)
+		require.True(t, ok)
+		assert.Len(t, paletted.Palette, 16)
+
+		// Each pixel is close to the original (more so without dithering, which spreads errors out)
+		delta := float64(0x4000)
+		if dither {
+			delta = 0x6000
+		}
+
+		assertSameColors(t, img, decoded, delta)
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	t.Parallel()
+
+	for n, expected := range map[int]string{
+		0:          "0 B",
+		1023:       "1023 B",
+		1024:       "1.0 KiB",
+		1536:       "1.5 KiB",
+		5 << 20:    "5.0 MiB",
+		3 << 30:    "3.0 GiB",
+		2048 << 30: "2.0 TiB",
+		2048 << 40: "2048.0 TiB",
+	} {
+		assert.Equal(t, expected, formatSize(n), "%d", n)
+	}
+}
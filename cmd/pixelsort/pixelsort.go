@@ -1,146 +1,209 @@
 package main
 
 import (
-	"errors"
+	"bufio"
+	"bytes"
+	"flag"
 	"fmt"
 	"image"
-	_ "image/gif"
-	"image/jpeg"
-	"image/png"
+	"image/color"
+	"image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"io/ioutil"
 	"log"
 	"os"
-	"path"
-	"sort"
+	"path/filepath"
 
 	_ "golang.org/x/image/tiff"
 
-	"github.com/dcormier/go-pixelsort/combiner/perceivedoption2"
+	_ "github.com/dcormier/go-pixelsort/netpbm"
 	"github.com/dcormier/go-pixelsort/sortablecolor"
 )
 
-const (
-	formatJpeg = "jpeg"
-	formatPng  = "png"
-	formatGif  = "gif"
-	formatTiff = "tiff"
-)
+func main() {
+	prog := filepath.Base(os.Args[0])
 
-func writeHelp(prog string) {
-	fmt.Printf("%v <input> [output_sorted.png]\n", prog)
-}
+	opts, err := parseOptions(prog, os.Args[1:], os.Stderr)
 
-func getArgs() (input, output string, err error) {
-	switch len(os.Args) {
-	case 2:
-		input = os.Args[1]
-		ext := path.Ext(input)
-		output = input[:len(input)-len(ext)] + "_sorted"
-		break
+	switch err := err.(type) {
+	case nil:
 
-	case 3:
-		input = os.Args[1]
-		ext := path.Ext(os.Args[2])
-		output = input[:len(os.Args[2])-len(ext)] + "_sorted"
-		break
+	case *usageError:
+		if !err.written {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", prog, err)
+			fmt.Fprintf(os.Stderr, "Run %s -h for usage.\n", prog)
+		}
+
+		os.Exit(2)
 
 	default:
-		writeHelp(os.Args[0])
-		err = errors.New("")
-		break
+		switch err {
+		case flag.ErrHelp:
+			return
+
+		case errList:
+			listCombiners(os.Stdout)
+			return
+		}
+
+		log.Fatal(err)
+	}
+
+	if opts.output == "-" {
+		messages = os.Stderr
 	}
 
-	return
+	if err = run(opts); err != nil {
+		fmt.Fprintln(messages, err)
+		log.Fatal(err)
+	}
 }
 
-func main() {
-	input, output, err := getArgs()
+// messages is where pixelsort says what it's doing. It's stderr when the output is written to
+// stdout.
+var messages io.Writer = os.Stdout
+
+// run sorts the input image and writes the output
+func run(opts *options) error {
+	if opts.time {
+		return runTime(opts)
+	}
+
+	in, err := openInput(opts.input)
 	if err != nil {
-		return
+		return err
 	}
 
-	reader, err := os.Open(input)
+	defer in.Close()
+
+	br := bufio.NewReader(in)
+	if isY4M(br) {
+		return runY4M(opts, br)
+	}
+
+	data, err := ioutil.ReadAll(br)
 	if err != nil {
-		fmt.Println(err)
-		log.Fatal(err)
+		return err
 	}
 
-	img, imgFmt, err := image.Decode(reader)
+	img, imgFmt, err := image.Decode(bytes.NewReader(data))
 	if err != nil {
-		fmt.Println(err)
-		log.Fatal(err)
+		return err
 	}
 
-	reader.Close()
+	writeMetadata(opts.input, imgFmt, img.Bounds())
 
-	combiner := perceivedoption2.New()
+	if imgFmt == formatGif {
+		anim, err := gif.DecodeAll(bytes.NewReader(data))
+		if err != nil {
+			return err
+		}
 
-	buffer, bounds := sortablecolor.SortableBufferFromImage(img, combiner)
+		if len(anim.Image) > 1 {
+			fmt.Fprintf(messages, "    Frames:     % 5d\n", len(anim.Image))
+			fmt.Fprintln(messages)
 
-	fmt.Println("Image metadata:")
-	fmt.Printf("    File:   %s\n", input)
-	fmt.Printf("    Format:     % 5s\n", imgFmt)
-	fmt.Printf("    Width:      % 5d\n", bounds.Dx())
-	fmt.Printf("    Height:     % 5d\n", bounds.Dy())
-	fmt.Printf("    Pixels: % 9d\n", bounds.Dx()*bounds.Dy())
-	fmt.Println()
+			switch {
+			case opts.animates():
+				fmt.Fprintln(messages, "Only the first frame will be animated")
 
-	sort.Sort(sort.Reverse(buffer))
+			case opts.format == formatGif:
+				return runAnimation(opts, anim)
 
-	img2 := image.NewRGBA64(bounds)
+			default:
+				fmt.Fprintf(messages, "Only the first frame will be written; use -format %s to keep them all\n", formatGif)
+			}
+		}
+	}
 
-	buffer.ToImage(img2)
+	fmt.Fprintln(messages)
 
-	outFmt := imgFmt
+	return sortAndWrite(opts, img)
+}
+
+// openInput opens the input file, or stdin if it's "-"
+func openInput(input string) (io.ReadCloser, error) {
+	if input == "-" {
+		return ioutil.NopCloser(os.Stdin), nil
+	}
 
-	switch imgFmt {
-	case formatJpeg:
-		outFmt = formatJpeg
+	return os.Open(input)
+}
 
-	case formatPng, "gif", "tiff":
-		outFmt = formatPng
+// writeMetadata writes out what the input is
+func writeMetadata(input, format string, bounds image.Rectangle) {
+	fmt.Fprintln(messages, "Image metadata:")
+	fmt.Fprintf(messages, "    File:   %s\n", input)
+	fmt.Fprintf(messages, "    Format:     % 5s\n", format)
+	fmt.Fprintf(messages, "    Width:      % 5d\n", bounds.Dx())
+	fmt.Fprintf(messages, "    Height:     % 5d\n", bounds.Dy())
+	fmt.Fprintf(messages, "    Pixels: % 9d\n", bounds.Dx()*bounds.Dy())
+}
 
-	default:
-		fmt.Printf("Not sure what to do with image format %q. Defaulting to writing a PNG.", imgFmt)
-		outFmt = formatPng
+// sortAndWrite sorts img (or animates the sort of it) and writes the output
+func sortAndWrite(opts *options, img image.Image) error {
+	if opts.animates() {
+		return runProgress(opts, img)
 	}
 
-	fmt.Printf("Output format is %v\n", outFmt)
+	fmt.Fprintf(messages, "Sorting by %v (%s, %s)\n", opts.combiner.Name(), opts.sort.Mode, orderName(opts.sort.Descending))
 
-	switch outFmt {
-	case formatJpeg:
-		output += ".jpg"
-		break
+	buffer, bounds := sortablecolor.SortableBufferFromImage(img, opts.combiner)
+	buffer.Sort(bounds, opts.sort)
 
-	case formatPng:
-		output += ".png"
-		break
+	img2 := sortablecolor.NewImageLike(img, bounds, opts.depth)
+
+	buffer.ToImage(img2)
+
+	fmt.Fprintf(messages, "Output format is %v (%s)\n", opts.format, describeImage(img2))
+
+	return writeOutput(opts, rawSize(img2), func(w io.Writer) error {
+		return formats[opts.format].encode(w, img2, opts)
+	})
+}
+
+// writeOutput encodes the output with encode, and writes it to the output file. raw is the size of
+// the pixels that are being written, for comparison.
+func writeOutput(opts *options, raw int, encode func(w io.Writer) error) error {
+	// Encode into memory first, so that the size is known before anything is written
+	encoded := &bytes.Buffer{}
+	if err := encode(encoded); err != nil {
+		return err
 	}
 
-	fmt.Printf("Output will be written to: %v\n", output)
-	fmt.Println()
+	fmt.Fprintf(messages, "Output size is %s (%.0f%% of the %s of raw pixels)\n", formatSize(encoded.Len()),
+		100*float64(encoded.Len())/float64(raw), formatSize(raw))
+	fmt.Fprintf(messages, "Output will be written to: %v\n", opts.output)
+	fmt.Fprintln(messages)
 
-	writer, err := os.Create(output)
-	if err != nil {
-		fmt.Println(err)
-		log.Fatal(err)
+	if opts.output == "-" {
+		_, err := os.Stdout.Write(encoded.Bytes())
+		return err
 	}
 
-	switch outFmt {
-	case formatJpeg:
-		var opts jpeg.Options
-		opts.Quality = 100
-		err = jpeg.Encode(writer, img2, &opts)
-		break
+	return ioutil.WriteFile(opts.output, encoded.Bytes(), 0644)
+}
 
-	case formatPng:
-		err = png.Encode(writer, img2)
-		break
+func orderName(descending bool) string {
+	if descending {
+		return "descending"
 	}
 
-	if err != nil {
-		fmt.Println(err)
-		log.Fatal(err)
+	return "ascending"
+}
+
+// describeImage describes the depth and color model of img, such as "8-bit grayscale"
+func describeImage(img image.Image) string {
+	if paletted, ok := img.(*image.Paletted); ok {
+		return fmt.Sprintf("paletted, %d colors", len(paletted.Palette))
+	}
+
+	kind := "color"
+	if img.ColorModel() == color.GrayModel || img.ColorModel() == color.Gray16Model {
+		kind = "grayscale"
 	}
 
-	writer.Close()
+	return fmt.Sprintf("%d-bit %s", sortablecolor.DepthOf(img), kind)
 }
@@ -19,6 +19,12 @@ import (
 
 	"github.com/dcormier/go-pixelsort/combiner"
 	"github.com/dcormier/go-pixelsort/combiner/all"
+	"github.com/dcormier/go-pixelsort/combiner/alphablend"
+	"github.com/dcormier/go-pixelsort/combiner/basic"
+	"github.com/dcormier/go-pixelsort/combiner/perceivedoption1"
+	"github.com/dcormier/go-pixelsort/combiner/perceivedoption2"
+	"github.com/dcormier/go-pixelsort/combiner/perceivedoption2noalpha"
+	"github.com/dcormier/go-pixelsort/combiner/standardobjective"
 	"github.com/dcormier/go-pixelsort/sortablecolor"
 )
 
@@ -143,10 +149,21 @@ func compareImageToFile(tb testing.TB, expectedFilename string, actualImg []byte
 	compareImages(tb, expectedFilename, expectedBytes, actualImg)
 }
 
-func testAllCombiners(t *testing.T, srcImgName string, srcImg image.Image) {
+// demoCombiners are the combiners that the (large) demo images are sorted with. Every combiner is
+// covered by the colors.png fixture; this keeps the size of the demo goldens in check.
+var demoCombiners = []combiner.Combiner{
+	alphablend.Combiner,
+	basic.Combiner,
+	perceivedoption1.Combiner,
+	perceivedoption2.Combiner,
+	perceivedoption2noalpha.Combiner,
+	standardobjective.Combiner,
+}
+
+func testCombiners(t *testing.T, srcImgName string, srcImg image.Image, combiners []combiner.Combiner) {
 	imgBaseName, _ := splitFilename(srcImgName)
 
-	for _, cmb := range all.All() {
+	for _, cmb := range combiners {
 		t.Run(cmb.Name(), func(t *testing.T) {
 			cmb := cmb
 
@@ -180,7 +197,7 @@ func TestImage(t *testing.T) {
 		savePNG(t, imgFilename, img)
 	}
 
-	testAllCombiners(t, imgFilename, img)
+	testCombiners(t, imgFilename, img, all.All())
 }
 
 func splitFilename(filename string) (base, ext string) {
@@ -203,7 +220,93 @@ func TestImageDemo(t *testing.T) {
 			t.Parallel()
 
 			srcImg := imageFromFile(t, srcFile)
-			testAllCombiners(t, srcFile, srcImg)
+			testCombiners(t, srcFile, srcImg, demoCombiners)
 		})
 	}
 }
+
+// runOn writes img to a PNG file in a temporary directory, sorts it with run and gives back the
+// decoded output
+func runOn(t *testing.T, img image.Image, depth sortablecolor.Depth) image.Image {
+	dir, err := ioutil.TempDir("", "pixelsort")
+	require.NoError(t, err)
+
+	defer os.RemoveAll(dir)
+
+	opts, err := parseOptions("pixelsort",
+		[]string{"-depth", depth.String(), filepath.Join(dir, "in.png")}, &bytes.Buffer{})
+	require.NoError(t, err)
+
+	savePNG(t, opts.input, img)
+	require.NoError(t, run(opts))
+
+	return imageFromFile(t, opts.output)
+}
+
+func TestRunKeepsColorModel(t *testing.T) {
+	t.Parallel()
+
+	bounds := image.Rect(0, 0, 4, 4)
+
+	gray := image.NewGray(bounds)
+	gray16 := image.NewGray16(bounds)
+	nrgba := image.NewNRGBA(bounds)
+	nrgba64 := image.NewNRGBA64(bounds)
+
+	for i := 0; i < 16; i++ {
+		x, y := i%4, i/4
+		gray.SetGray(x, y, color.Gray{Y: uint8(i * 16)})
+		gray16.SetGray16(x, y, color.Gray16{Y: uint16(i * 4001)})
+		nrgba.SetNRGBA(x, y, color.NRGBA{R: uint8(i * 16), G: 0x80, B: 0x40, A: 0xff})
+		nrgba64.SetNRGBA64(x, y, color.NRGBA64{R: uint16(i * 4001), G: 0x1234, B: 0x4321, A: 0xffff})
+	}
+
+	for _, test := range []struct {
+		src      image.Image
+		depth    sortablecolor.Depth
+		expected color.Model
+	}{
+		{gray, sortablecolor.SameDepth, color.GrayModel},
+		{gray16, sortablecolor.SameDepth, color.Gray16Model},
+		{gray, sortablecolor.Depth16, color.Gray16Model},
+		{nrgba, sortablecolor.SameDepth, color.NRGBAModel},
+		{nrgba64, sortablecolor.SameDepth, color.NRGBA64Model},
+		{nrgba64, sortablecolor.Depth8, color.NRGBAModel},
+	} {
+		sorted := runOn(t, test.src, test.depth)
+
+		// The PNG decoder gives opaque images as RGBA, rather than NRGBA
+		model := sorted.ColorModel()
+		switch model {
+		case color.RGBAModel:
+			model = color.NRGBAModel
+		case color.RGBA64Model:
+			model = color.NRGBA64Model
+		}
+
+		assert.Equal(t, test.expected, model, "%T at depth %v", test.src, test.depth)
+	}
+}
+
+func TestRunKeepsPalette(t *testing.T) {
+	t.Parallel()
+
+	palette := color.Palette{
+		color.NRGBA{R: 0xff, A: 0xff},
+		color.NRGBA{G: 0xff, A: 0xff},
+		color.NRGBA{B: 0xff, A: 0x80},
+	}
+
+	src := image.NewPaletted(image.Rect(0, 0, 8, 8), palette)
+	for i := range src.Pix {
+		src.Pix[i] = uint8(i % len(palette))
+	}
+
+	sorted, ok := runOn(t, src, sortablecolor.SameDepth).(*image.Paletted)
+	require.True(t, ok)
+	assert.Len(t, sorted.Palette, len(palette))
+
+	for i, c := range palette {
+		assert.Equal(t, color.NRGBAModel.Convert(c), color.NRGBAModel.Convert(sorted.Palette[i]))
+	}
+}
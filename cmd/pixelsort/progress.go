@@ -0,0 +1,299 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"io"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/dcormier/go-pixelsort/quantize"
+	"github.com/dcormier/go-pixelsort/sortablecolor"
+)
+
+const (
+	// animateTravel moves each pixel in a straight line from where it was to where it's sorted to
+	animateTravel = "travel"
+
+	// animateMerge shows the passes of a merge sort, as the sorted runs double in length
+	animateMerge = "merge"
+)
+
+// runProgress animates the sort of img (from the original image to the sorted one, or as the
+// sweeps change it), and writes it out as an animated GIF, or as a numbered sequence of images in
+// any other format
+func runProgress(opts *options, img image.Image) error {
+	var frames frameFunc
+
+	switch {
+	case len(opts.sweeps) > 0:
+		fmt.Fprintf(messages, "Sweeping %s over %d frames, sorting by %v (%s, %s)\n", opts.sweeps.String(), opts.frames,
+			opts.combiner.Name(), opts.sort.Mode, orderName(opts.sort.Descending))
+
+		frames = sweepFrames
+
+	default:
+		fmt.Fprintf(messages, "Animating the sort by %v (%s, %s) in %d frames, by %s\n", opts.combiner.Name(),
+			opts.sort.Mode, orderName(opts.sort.Descending), opts.frames, opts.animate)
+
+		frames = mergeFrames
+		if opts.animate == animateTravel {
+			frames = travelFrames
+		}
+	}
+
+	if opts.format == formatGif {
+		return writeProgressGIF(opts, img, frames)
+	}
+
+	return writeSequence(opts, img, frames)
+}
+
+// frameFunc gives each frame of an animation of the sort of img to emit, in order. When a frame is
+// the same as the one before it, the same image is given again.
+type frameFunc func(opts *options, img image.Image, emit func(frame image.Image) error) error
+
+// mergeFrames shows the passes of a bottom-up merge sort of img. Each pass sorts runs twice the
+// length of the ones before, so there are only as many distinct frames as it takes to double up to
+// the longest run. With more frames than that, passes are held for more than one frame; with
+// fewer, some are skipped.
+func mergeFrames(opts *options, img image.Image, emit func(frame image.Image) error) error {
+	buffer, bounds := sortablecolor.SortableBufferFromImage(img, opts.combiner)
+
+	passes := 0
+	for size := 1; size < longestRun(bounds, opts.sort.Mode); size *= 2 {
+		passes++
+	}
+
+	sortOpts := opts.sort
+	sortOpts.Stable = true
+
+	var frame image.Image
+	lastPass := -1
+
+	for i := 0; i < opts.frames; i++ {
+		pass := int(math.Round(float64(i*passes) / float64(opts.frames-1)))
+
+		if pass != lastPass {
+			// Each pass carries on from the one before, since its blocks are made of whole blocks
+			// that have already been sorted
+			sortOpts.BlockSize = 1 << uint(pass)
+			buffer.Sort(bounds, sortOpts)
+
+			sorted := sortablecolor.NewImageLike(img, bounds, opts.depth)
+			buffer.ToImage(sorted)
+
+			frame, lastPass = sorted, pass
+		}
+
+		if err := emit(frame); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// longestRun gives the most pixels that are sorted together in the given mode
+func longestRun(bounds image.Rectangle, mode sortablecolor.Mode) int {
+	switch mode {
+	case sortablecolor.Global:
+		return bounds.Dx() * bounds.Dy()
+
+	case sortablecolor.Columns:
+		return bounds.Dy()
+	}
+
+	return bounds.Dx()
+}
+
+// travelFrames moves each pixel of img from where it was to where it's sorted to, easing in and
+// out. Where pixels pass each other, the ones later in the sorted order are drawn on top; where
+// they've all moved away, the frame is transparent (or black, if it has no alpha).
+func travelFrames(opts *options, img image.Image, emit func(frame image.Image) error) error {
+	buffer, bounds := sortablecolor.SortableBufferFromImage(img, opts.combiner)
+	buffer.Sort(bounds, opts.sort)
+
+	width := bounds.Dx()
+
+	for i := 0; i < opts.frames; i++ {
+		t := float64(i) / float64(opts.frames-1)
+		t = t * t * (3 - 2*t)
+
+		frame := travelCanvas(img, bounds, opts.depth)
+
+		for to := range buffer {
+			from := buffer[to].Origin()
+
+			x := lerp(from%width, to%width, t)
+			y := lerp(from/width, to/width, t)
+
+			frame.Set(bounds.Min.X+x, bounds.Min.Y+y, buffer[to].Color)
+		}
+
+		if err := emit(frame); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// travelCanvas gives an empty image like img to draw a frame of travelFrames on. Pixels pass over
+// each other in any part of the image, so paletted images don't necessarily have a color for the
+// gaps, and get drawn on an NRGBA image instead.
+func travelCanvas(img image.Image, bounds image.Rectangle, depth sortablecolor.Depth) sortablecolor.SettableImage {
+	canvas := sortablecolor.NewImageLike(img, bounds, depth)
+	if _, ok := canvas.(*image.Paletted); ok {
+		return image.NewNRGBA(bounds)
+	}
+
+	return canvas
+}
+
+// lerp gives the point a fraction t of the way from a to b, rounded to the nearest pixel
+func lerp(a, b int, t float64) int {
+	return a + int(math.Round(float64(b-a)*t))
+}
+
+// writeProgressGIF writes the frames of an animation as an animated GIF. Every frame has the same
+// palette, picked from the colors of img (which the frames only move around), along with
+// transparency for the gaps that travelFrames can leave.
+func writeProgressGIF(opts *options, img image.Image, frames frameFunc) error {
+	colors := opts.gifColors
+	if opts.animate == animateTravel {
+		colors--
+	}
+
+	palette := quantize.MedianCut{}.Quantize(make(color.Palette, 0, colors), img)
+	if opts.animate == animateTravel {
+		palette = append(palette, color.Transparent)
+	}
+
+	var drawer draw.Drawer = draw.FloydSteinberg
+	if !opts.dither {
+		drawer = draw.Src
+	}
+
+	bounds := img.Bounds()
+	delay := frameDelay(opts)
+
+	anim := &gif.GIF{
+		Config: image.Config{
+			ColorModel: palette,
+			Width:      bounds.Dx(),
+			Height:     bounds.Dy(),
+		},
+	}
+
+	var last image.Image
+	err := frames(opts, img, func(frame image.Image) error {
+		// A frame that's the same as the one before is shown for longer, rather than again
+		if frame == last {
+			anim.Delay[len(anim.Delay)-1] += delay
+			return nil
+		}
+
+		paletted := image.NewPaletted(bounds, palette)
+		drawer.Draw(paletted, bounds, frame, bounds.Min)
+
+		anim.Image = append(anim.Image, paletted)
+		anim.Delay = append(anim.Delay, delay)
+		anim.Disposal = append(anim.Disposal, gif.DisposalBackground)
+		last = frame
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	raw := 0
+	for _, frame := range anim.Image {
+		raw += rawSize(frame)
+	}
+
+	fmt.Fprintf(messages, "Output format is %v (%d distinct frames, %d colors)\n", formatGif, len(anim.Image), len(palette))
+
+	if length := gifDuration(opts); length != opts.duration {
+		fmt.Fprintf(messages, "GIF frames are shown for whole hundredths of a second (at least 2 of them), so the "+
+			"animation takes %v, not %v\n", length, opts.duration)
+	}
+
+	return writeOutput(opts, raw, func(w io.Writer) error {
+		return gif.EncodeAll(w, anim)
+	})
+}
+
+// frameDelay gives how long to show each frame of an animated GIF for, in hundredths of a second.
+// Many viewers show frames with delays under 2 for much longer, so that's the shortest it can be.
+func frameDelay(opts *options) int {
+	delay := int(math.Round(float64(opts.duration) / float64(opts.frames) / float64(10*time.Millisecond)))
+	if delay < 2 {
+		return 2
+	}
+
+	return delay
+}
+
+// gifDuration gives how long an animated GIF takes to play, which can be different from
+// opts.duration because of frameDelay's rounding
+func gifDuration(opts *options) time.Duration {
+	return time.Duration(frameDelay(opts)*opts.frames) * 10 * time.Millisecond
+}
+
+// writeSequence writes the frames of an animation to numbered files (see sequenceName), as they're
+// made
+func writeSequence(opts *options, img image.Image, frames frameFunc) error {
+	fmt.Fprintf(messages, "Output format is a sequence of %d %v images\n", opts.frames, opts.format)
+	fmt.Fprintf(messages, "Output will be written to: %v to %v\n", sequenceName(opts.output, 0, opts.frames),
+		sequenceName(opts.output, opts.frames-1, opts.frames))
+
+	i, raw, size := 0, 0, 0
+
+	err := frames(opts, img, func(frame image.Image) error {
+		encoded := &bytes.Buffer{}
+		if err := formats[opts.format].encode(encoded, frame, opts); err != nil {
+			return err
+		}
+
+		if err := ioutil.WriteFile(sequenceName(opts.output, i, opts.frames), encoded.Bytes(), 0644); err != nil {
+			return err
+		}
+
+		i++
+		raw += rawSize(frame)
+		size += encoded.Len()
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(messages, "Output size is %s (%.0f%% of the %s of raw pixels)\n", formatSize(size),
+		100*float64(size)/float64(raw), formatSize(raw))
+	fmt.Fprintln(messages)
+
+	return nil
+}
+
+// sequenceName gives the name of frame i (of n) of a sequence written to output, which is numbered
+// from 1 before the extension, such as "out_0001.png"
+func sequenceName(output string, i, n int) string {
+	digits := len(strconv.Itoa(n))
+	if digits < 4 {
+		digits = 4
+	}
+
+	ext := filepath.Ext(output)
+
+	return fmt.Sprintf("%s_%0*d%s", output[:len(output)-len(ext)], digits, i+1, ext)
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dcormier/go-pixelsort/combiner"
+	"github.com/dcormier/go-pixelsort/sortablecolor"
+)
+
+// progressImage is a small image with every pixel a different shade of red
+func progressImage() *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, 6, 4))
+	for i := 0; i < 24; i++ {
+		img.SetNRGBA(i%6, i/6, color.NRGBA{R: uint8((i * 7 % 24) * 10), A: 0xff})
+	}
+
+	return img
+}
+
+// collectFrames gives every frame that frames emits
+func collectFrames(t *testing.T, frames frameFunc, opts *options, img image.Image) []image.Image {
+	var collected []image.Image
+	require.NoError(t, frames(opts, img, func(frame image.Image) error {
+		collected = append(collected, frame)
+		return nil
+	}))
+
+	return collected
+}
+
+func progressOptions(t *testing.T, args ...string) *options {
+	opts, err := parseOptions("pixelsort", append(args, "in.png"), &bytes.Buffer{})
+	require.NoError(t, err)
+
+	return opts
+}
+
+// sortedImage gives img sorted the way opts say, with equal pixels kept in order
+func sortedImage(img image.Image, opts *options) image.Image {
+	buffer, bounds := sortablecolor.SortableBufferFromImage(img, opts.combiner)
+
+	sortOpts := opts.sort
+	sortOpts.Stable = true
+	buffer.Sort(bounds, sortOpts)
+
+	sorted := sortablecolor.NewImageLike(img, bounds, opts.depth)
+	buffer.ToImage(sorted)
+
+	return sorted
+}
+
+func TestMergeFrames(t *testing.T) {
+	t.Parallel()
+
+	img := progressImage()
+
+	for _, mode := range sortablecolor.Modes {
+		opts := progressOptions(t, "-combiner", "red", "-mode", mode.String(), "-animate", "merge",
+			"-frames", "40")
+		opts.sort.Lower, opts.sort.Upper = 0, combiner.MetadataOf(opts.combiner).Key(0.5)
+
+		frames := collectFrames(t, mergeFrames, opts, img)
+		require.Len(t, frames, 40, "%s", mode)
+
+		assert.Equal(t, img.Pix, frames[0].(*image.NRGBA).Pix, "%s", mode)
+		assert.Equal(t, sortedImage(img, opts), frames[len(frames)-1], "%s", mode)
+
+		// There's a frame for the original image, and for each pass
+		distinct := 1
+		for i := 1; i < len(frames); i++ {
+			if frames[i] != frames[i-1] {
+				distinct++
+			}
+		}
+
+		passes := map[sortablecolor.Mode]int{
+			sortablecolor.Global:    5,
+			sortablecolor.Rows:      3,
+			sortablecolor.Columns:   2,
+			sortablecolor.Intervals: 3,
+		}[mode]
+
+		assert.Equal(t, passes+1, distinct, "%s", mode)
+	}
+
+	// With fewer frames than passes, some passes are skipped, but it still ends up sorted
+	opts := progressOptions(t, "-combiner", "red", "-animate", "merge", "-frames", "2")
+
+	frames := collectFrames(t, mergeFrames, opts, img)
+	require.Len(t, frames, 2)
+	assert.Equal(t, sortedImage(img, opts), frames[1])
+}
+
+func TestTravelFrames(t *testing.T) {
+	t.Parallel()
+
+	img := progressImage()
+	opts := progressOptions(t, "-combiner", "red", "-mode", "columns", "-animate", "travel", "-frames", "5")
+
+	frames := collectFrames(t, travelFrames, opts, img)
+	require.Len(t, frames, 5)
+
+	assert.Equal(t, img.Pix, frames[0].(*image.NRGBA).Pix)
+	assert.Equal(t, colorCounts(sortedImage(img, opts)), colorCounts(frames[4]))
+
+	// Pixels only move up and down their columns, so every column keeps its colors along the way
+	for _, frame := range frames {
+		for x := 0; x < 6; x++ {
+			column := image.Rect(x, 0, x+1, 4)
+			counts := colorCounts(frame.(*image.NRGBA).SubImage(column))
+
+			for c, n := range colorCounts(img.SubImage(column)) {
+				assert.True(t, counts[c] <= n, "column %d has more of %v than it started with", x, c)
+			}
+		}
+	}
+}
+
+func TestRunAnimate(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "pixelsort")
+	require.NoError(t, err)
+
+	defer os.RemoveAll(dir)
+
+	input := filepath.Join(dir, "in.png")
+	savePNG(t, input, progressImage())
+
+	opts, err := parseOptions("pixelsort",
+		[]string{"-combiner", "red", "-animate", "merge", "-frames", "12", "-duration", "1200ms", input,
+			filepath.Join(dir, "out.gif")}, &bytes.Buffer{})
+	require.NoError(t, err)
+	require.NoError(t, run(opts))
+
+	f, err := os.Open(opts.output)
+	require.NoError(t, err)
+
+	defer f.Close()
+
+	anim, err := gif.DecodeAll(f)
+	require.NoError(t, err)
+
+	// Frames that are held are shown for longer, rather than repeated
+	require.Len(t, anim.Image, 6)
+
+	total := 0
+	for _, delay := range anim.Delay {
+		total += delay
+	}
+
+	assert.Equal(t, 120, total)
+	assert.Equal(t, colorCounts(progressImage()), colorCounts(anim.Image[5]))
+
+	opts, err = parseOptions("pixelsort",
+		[]string{"-combiner", "red", "-animate", "travel", "-frames", "3", input, filepath.Join(dir, "seq.png")},
+		&bytes.Buffer{})
+	require.NoError(t, err)
+	require.NoError(t, run(opts))
+
+	for i, name := range []string{"seq_0001.png", "seq_0002.png", "seq_0003.png"} {
+		frame := imageFromFile(t, filepath.Join(dir, name))
+
+		if i == 2 {
+			assert.Equal(t, colorCounts(sortedImage(progressImage(), opts)), colorCounts(frame))
+		}
+	}
+
+	_, err = os.Stat(filepath.Join(dir, "seq.png"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestFrameDelay(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, 10, frameDelay(&options{frames: 30, duration: 3 * time.Second}))
+	assert.Equal(t, 2, frameDelay(&options{frames: 100, duration: time.Second}))
+
+	assert.Equal(t, 3*time.Second, gifDuration(&options{frames: 30, duration: 3 * time.Second}))
+	assert.Equal(t, 900*time.Millisecond, gifDuration(&options{frames: 30, duration: time.Second}))
+	assert.Equal(t, 2*time.Second, gifDuration(&options{frames: 100, duration: time.Second}))
+}
+
+func TestSequenceName(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "out_0001.png", sequenceName("out.png", 0, 30))
+	assert.Equal(t, "dir.v2/out_0030.tiff", sequenceName("dir.v2/out.tiff", 29, 30))
+	assert.Equal(t, "out_00100.png", sequenceName("out.png", 99, 12345))
+}
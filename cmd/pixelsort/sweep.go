@@ -0,0 +1,254 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/dcormier/go-pixelsort/combiner"
+	"github.com/dcormier/go-pixelsort/sortablecolor"
+)
+
+const (
+	sweepLower = "lower"
+	sweepUpper = "upper"
+)
+
+// sweep is a numeric option that changes over the frames of an animation, going in a straight
+// line from each of its keyframes to the next. It's either -lower, -upper, or a parameter of the
+// -combiner spec.
+type sweep struct {
+	name string
+
+	// keys are the values at the keyframes, which are spread evenly over the animation
+	keys []float64
+
+	// integer is whether the values should be rounded to whole numbers, because the sweep is of
+	// a parameter that the combiner reads as an integer. It's set by checkSweeps.
+	integer bool
+}
+
+// parseSweep parses a sweep such as "lower=0.2:0.8", with two or more keyframes separated by ":"s
+func parseSweep(s string) (sweep, error) {
+	eq := strings.IndexByte(s, '=')
+	if eq < 0 {
+		return sweep{}, fmt.Errorf("expected name=from:to, found %q", s)
+	}
+
+	sw := sweep{name: strings.TrimSpace(s[:eq])}
+	if sw.name == "" {
+		return sweep{}, fmt.Errorf("missing the name of the option to sweep in %q", s)
+	}
+
+	values := strings.Split(s[eq+1:], ":")
+	if len(values) < 2 {
+		return sweep{}, fmt.Errorf("%s needs at least two keyframes, such as %s=0.2:0.8", sw.name, sw.name)
+	}
+
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+
+		key, err := strconv.ParseFloat(value, 64)
+		if err != nil || math.IsNaN(key) || math.IsInf(key, 0) {
+			return sweep{}, fmt.Errorf("%s: %q isn't a number", sw.name, value)
+		}
+
+		sw.keys = append(sw.keys, key)
+	}
+
+	return sw, nil
+}
+
+// isCombinerParam is whether the sweep is of a parameter of the combiner, rather than a threshold
+func (sw sweep) isCombinerParam() bool {
+	return sw.name != sweepLower && sw.name != sweepUpper
+}
+
+// at gives the value of the sweep at t, from 0 (the first keyframe) to 1 (the last)
+func (sw sweep) at(t float64) float64 {
+	pos := t * float64(len(sw.keys)-1)
+
+	i := int(pos)
+	if i >= len(sw.keys)-1 {
+		return sw.keys[len(sw.keys)-1]
+	}
+
+	v := sw.keys[i] + (sw.keys[i+1]-sw.keys[i])*(pos-float64(i))
+	if sw.integer {
+		v = math.Round(v)
+	}
+
+	return v
+}
+
+// format formats a value of the sweep for a combiner spec
+func (sw sweep) format(v float64) string {
+	if sw.integer {
+		return strconv.Itoa(int(v))
+	}
+
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// sweeps is a flag.Value for -sweep, which can be given more than once
+type sweeps []sweep
+
+func (s *sweeps) String() string {
+	if s == nil {
+		return ""
+	}
+
+	names := make([]string, len(*s))
+	for i, sw := range *s {
+		names[i] = sw.name
+	}
+
+	return strings.Join(names, ", ")
+}
+
+func (s *sweeps) Set(value string) error {
+	sw, err := parseSweep(value)
+	if err != nil {
+		return err
+	}
+
+	for _, other := range *s {
+		if other.name == sw.name {
+			return fmt.Errorf("%s is swept more than once", sw.name)
+		}
+	}
+
+	*s = append(*s, sw)
+
+	return nil
+}
+
+// checkSweeps makes sure that the sweeps can be used with the rest of the options, and that
+// every keyframe gives options that work. Sweeps of integer parameters of the combiner are marked
+// as such, so that their values are rounded.
+func checkSweeps(opts *options) error {
+	for i := range opts.sweeps {
+		sw := &opts.sweeps[i]
+
+		if !sw.isCombinerParam() {
+			if opts.sort.Mode != sortablecolor.Intervals {
+				return usageErrorf("-sweep %s only applies to -mode %s", sw.name, sortablecolor.Intervals)
+			}
+
+			for _, key := range sw.keys {
+				if key < 0 || key > 1 {
+					return usageErrorf("-sweep %s must stay within [0, 1]", sw.name)
+				}
+			}
+
+			continue
+		}
+
+		if opts.combinerSpec == "" {
+			return usageErrorf("-sweep %s is a parameter of -combiner, so it can't be used with "+
+				"-combiner-expr or -reference", sw.name)
+		}
+
+		ints, err := combiner.IntParams(opts.combinerSpec)
+		if err != nil {
+			return usageErrorf("-sweep %s: %v", sw.name, err)
+		}
+
+		if sw.integer = ints[sw.name]; sw.integer {
+			for _, key := range sw.keys {
+				if key != math.Trunc(key) {
+					return usageErrorf("-sweep %s: %v isn't a whole number", sw.name, key)
+				}
+			}
+		}
+
+		for k := range sw.keys {
+			if _, err := opts.at(float64(k) / float64(len(sw.keys)-1)); err != nil {
+				return usageErrorf("-sweep %s: %v", sw.name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// at gives the options for the point t (from 0 to 1) of the sweeps
+func (opts *options) at(t float64) (*options, error) {
+	at := *opts
+	spec := opts.combinerSpec
+
+	for _, sw := range opts.sweeps {
+		v := sw.at(t)
+
+		switch sw.name {
+		case sweepLower:
+			at.lower = v
+
+		case sweepUpper:
+			at.upper = v
+
+		default:
+			var err error
+			if spec, err = combiner.WithParam(spec, sw.name, sw.format(v)); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if spec != opts.combinerSpec {
+		var err error
+		if at.combiner, err = makeCombiner(spec, opts.cacheSize); err != nil {
+			return nil, err
+		}
+	}
+
+	md := combiner.MetadataOf(at.combiner)
+	at.sort.Lower, at.sort.Upper = md.Key(at.lower), md.Key(at.upper)
+
+	return &at, nil
+}
+
+// sweepFrames sorts img once for each frame, with the swept options at their values for that
+// frame. Unless a parameter of the combiner is swept, img only has to be read once.
+func sweepFrames(opts *options, img image.Image, emit func(frame image.Image) error) error {
+	rereads := false
+	for _, sw := range opts.sweeps {
+		rereads = rereads || sw.isCombinerParam()
+	}
+
+	var (
+		buffer sortablecolor.SortableBuffer
+		bounds image.Rectangle
+	)
+
+	if !rereads {
+		buffer, bounds = sortablecolor.SortableBufferFromImage(img, opts.combiner)
+	}
+
+	for i := 0; i < opts.frames; i++ {
+		frameOpts, err := opts.at(float64(i) / float64(opts.frames-1))
+		if err != nil {
+			return err
+		}
+
+		var frameBuffer sortablecolor.SortableBuffer
+		if rereads {
+			frameBuffer, bounds = sortablecolor.SortableBufferFromImage(img, frameOpts.combiner)
+		} else {
+			frameBuffer = append(sortablecolor.SortableBuffer(nil), buffer...)
+		}
+
+		frameBuffer.Sort(bounds, frameOpts.sort)
+
+		frame := sortablecolor.NewImageLike(img, bounds, opts.depth)
+		frameBuffer.ToImage(frame)
+
+		if err := emit(frame); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
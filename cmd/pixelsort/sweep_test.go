@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/gif"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSweep(t *testing.T) {
+	t.Parallel()
+
+	sw, err := parseSweep("lower=0.2:0.8")
+	require.NoError(t, err)
+	assert.Equal(t, sweep{name: "lower", keys: []float64{0.2, 0.8}}, sw)
+
+	sw, err = parseSweep(" radius = 1 : 8 : 2 ")
+	require.NoError(t, err)
+	assert.Equal(t, sweep{name: "radius", keys: []float64{1, 8, 2}}, sw)
+
+	for s, msg := range map[string]string{
+		"lower":         `expected name=from:to, found "lower"`,
+		"=0:1":          `missing the name of the option to sweep in "=0:1"`,
+		"lower=0.5":     "lower needs at least two keyframes, such as lower=0.2:0.8",
+		"lower=0:x":     `lower: "x" isn't a number`,
+		"lower=0:NaN":   `lower: "NaN" isn't a number`,
+		"lower=0.1::.9": `lower: "" isn't a number`,
+	} {
+		_, err := parseSweep(s)
+		assert.EqualError(t, err, msg, s)
+	}
+
+	var s sweeps
+	require.NoError(t, s.Set("lower=0:1"))
+	require.NoError(t, s.Set("origin=0:360"))
+	assert.EqualError(t, s.Set("lower=1:0"), "lower is swept more than once")
+	assert.Equal(t, "lower, origin", s.String())
+}
+
+func TestSweepAt(t *testing.T) {
+	t.Parallel()
+
+	sw := sweep{name: "lower", keys: []float64{0.2, 0.8, 0.4}}
+	for tt, expected := range map[float64]float64{0: 0.2, 0.25: 0.5, 0.5: 0.8, 0.75: 0.6, 1: 0.4} {
+		assert.InDelta(t, expected, sw.at(tt), 1e-9, "at %v", tt)
+	}
+
+	sw = sweep{name: "radius", keys: []float64{1, 4}, integer: true}
+	assert.Equal(t, 2.0, sw.at(0.4))
+	assert.Equal(t, "2", sw.format(sw.at(0.4)))
+	assert.Equal(t, "0.25", sweep{}.format(0.25))
+}
+
+func TestOptionsAt(t *testing.T) {
+	t.Parallel()
+
+	opts := progressOptions(t, "-combiner", "hue?origin=10", "-mode", "intervals", "-sweep", "upper=0.5:1",
+		"-sweep", "origin=0:180:90", "-cache", "16")
+
+	at, err := opts.at(0.25)
+	require.NoError(t, err)
+	assert.Equal(t, "hue (from 90°)", at.combiner.Name())
+	assert.Equal(t, 0.625, at.upper)
+	assert.Equal(t, opts.lower, at.lower)
+
+	// The original options are left alone
+	assert.Equal(t, "hue (from 10°)", opts.combiner.Name())
+	assert.Equal(t, "hue?origin=10", opts.combinerSpec)
+}
+
+func TestSweepRounding(t *testing.T) {
+	t.Parallel()
+
+	// Thresholds are never rounded, even when their keyframes are whole numbers
+	opts := progressOptions(t, "-mode", "intervals", "-sweep", "upper=0:1", "-sweep", "lower=1:0")
+	at, err := opts.at(0.25)
+	require.NoError(t, err)
+	assert.Equal(t, 0.25, at.upper)
+	assert.Equal(t, 0.75, at.lower)
+
+	// Nor are parameters of the combiner that aren't integers
+	opts = progressOptions(t, "-combiner", "expr?e=r", "-sweep", "e=1:0")
+	assert.False(t, opts.sweeps[0].integer)
+	at, err = opts.at(0.25)
+	require.NoError(t, err)
+	assert.Equal(t, "expression (0.75)", at.combiner.Name())
+
+	// Integer parameters are
+	opts = progressOptions(t, "-combiner", "localcontrast?radius=3", "-sweep", "radius=1:5")
+	assert.True(t, opts.sweeps[0].integer)
+	at, err = opts.at(0.4)
+	require.NoError(t, err)
+	assert.Equal(t, "local contrast (radius 3)", at.combiner.Name())
+}
+
+func TestSweepFrames(t *testing.T) {
+	t.Parallel()
+
+	img := progressImage()
+
+	opts := progressOptions(t, "-combiner", "red", "-mode", "intervals", "-lower", "0",
+		"-sweep", "upper=0:1", "-frames", "5")
+
+	frames := collectFrames(t, sweepFrames, opts, img)
+	require.Len(t, frames, 5)
+
+	for i, frame := range frames {
+		at, err := opts.at(float64(i) / 4)
+		require.NoError(t, err)
+
+		expected := sortedImage(img, at)
+		assert.Equal(t, colorCounts(expected), colorCounts(frame), "frame %d", i)
+	}
+
+	// Nothing is sorted with the upper threshold at 0, and everything in each row is at 1
+	assert.Equal(t, img.Pix, frames[0].(*image.NRGBA).Pix)
+
+	rows := progressOptions(t, "-combiner", "red", "-mode", "rows")
+	assert.Equal(t, sortedImage(img, rows), frames[4])
+
+	// Sweeping a parameter of the combiner sorts by a different combiner for each frame
+	opts = progressOptions(t, "-combiner", "expr?e=r", "-sweep", "e=1:0", "-frames", "3")
+	frames = collectFrames(t, sweepFrames, opts, img)
+	require.Len(t, frames, 3)
+
+	assert.Equal(t, img.Pix, frames[0].(*image.NRGBA).Pix, "a constant combiner leaves the image as it is")
+}
+
+func TestRunSweep(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "pixelsort")
+	require.NoError(t, err)
+
+	defer os.RemoveAll(dir)
+
+	input := filepath.Join(dir, "in.png")
+	savePNG(t, input, progressImage())
+
+	opts, err := parseOptions("pixelsort",
+		[]string{"-combiner", "red", "-mode", "intervals", "-sweep", "lower=1:0", "-frames", "4", input,
+			filepath.Join(dir, "out.gif")}, &bytes.Buffer{})
+	require.NoError(t, err)
+	require.NoError(t, run(opts))
+
+	f, err := os.Open(opts.output)
+	require.NoError(t, err)
+
+	defer f.Close()
+
+	anim, err := gif.DecodeAll(f)
+	require.NoError(t, err)
+	require.Len(t, anim.Image, 4)
+
+	for _, frame := range anim.Image {
+		assert.Equal(t, colorCounts(progressImage()), colorCounts(frame))
+	}
+}
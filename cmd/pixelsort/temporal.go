@@ -0,0 +1,288 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"io"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"strings"
+
+	"github.com/dcormier/go-pixelsort/sortablecolor"
+	"github.com/dcormier/go-pixelsort/y4m"
+)
+
+// defaultDelay is how long each frame of an animated GIF is shown for (in hundredths of a second)
+// when the input doesn't say
+const defaultDelay = 10
+
+// timeSource is a sequence of frames to sort through time, along with how they were timed
+type timeSource struct {
+	format string
+	frames []image.Image
+
+	// delay is how long to show each frame for, in hundredths of a second, and loopCount is how
+	// many times to loop them, for GIF output
+	delay     []int
+	loopCount int
+
+	// header is the header of a Y4M source, for Y4M output
+	header *y4m.Header
+}
+
+// isSequence is whether input is a pattern for a sequence of images, such as "frames/*.png"
+func isSequence(input string) bool {
+	return strings.ContainsAny(input, "*?[")
+}
+
+// runTime sorts each pixel of the frames of the input through time, rather than across each frame,
+// and writes them out as an animated GIF, a Y4M video or a numbered sequence of images
+func runTime(opts *options) error {
+	src, err := readTimeSource(opts.input)
+	if err != nil {
+		return err
+	}
+
+	writeMetadata(opts.input, src.format, src.frames[0].Bounds())
+	fmt.Fprintf(messages, "    Frames:     % 5d\n", len(src.frames))
+	fmt.Fprintln(messages)
+
+	fmt.Fprintf(messages, "Sorting each pixel through time by %v (%s, %s)\n", opts.combiner.Name(), opts.sort.Mode,
+		orderName(opts.sort.Descending))
+
+	v, err := sortablecolor.SortableVolumeFromFrames(src.frames, opts.combiner)
+	if err != nil {
+		return err
+	}
+
+	v.SortTime(opts.sort)
+
+	switch opts.format {
+	case formatGif:
+		return writeTimeGIF(opts, src, v)
+
+	case formatY4M:
+		return writeTimeVideo(opts, src, v)
+	}
+
+	// Every frame of the sequence is numbered, however many -frames says there are
+	seqOpts := *opts
+	seqOpts.frames = v.Frames
+
+	return writeSequence(&seqOpts, nil, func(_ *options, _ image.Image, emit func(frame image.Image) error) error {
+		for i := 0; i < v.Frames; i++ {
+			frame := sortablecolor.NewImageLike(src.frames[i], v.Bounds, opts.depth)
+			v.Frame(i).ToImage(frame)
+
+			if err := emit(frame); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func writeTimeGIF(opts *options, src *timeSource, v *sortablecolor.SortableVolume) error {
+	frames := make([]*image.NRGBA, v.Frames)
+	for i := range frames {
+		frames[i] = image.NewNRGBA(v.Bounds)
+		v.Frame(i).ToImage(frames[i])
+	}
+
+	anim := framesToGIF(frames, src.delay, src.loopCount, opts)
+
+	fmt.Fprintf(messages, "Output format is %v (%d frames)\n", formatGif, len(anim.Image))
+
+	return writeOutput(opts, len(anim.Image)*rawSize(anim.Image[0]), func(w io.Writer) error {
+		return gif.EncodeAll(w, anim)
+	})
+}
+
+func writeTimeVideo(opts *options, src *timeSource, v *sortablecolor.SortableVolume) error {
+	header := y4m.Header{Width: v.Bounds.Dx(), Height: v.Bounds.Dy(), Colorspace: y4m.C444}
+	if src.header != nil {
+		header = *src.header
+	} else if src.frames[0].ColorModel() == color.GrayModel || src.frames[0].ColorModel() == color.Gray16Model {
+		header.Colorspace = y4m.Mono
+	}
+
+	fmt.Fprintf(messages, "Output format is %v (%d frames)\n", formatY4M, v.Frames)
+	fmt.Fprintf(messages, "Output will be written to: %v\n", opts.output)
+	fmt.Fprintln(messages)
+
+	out, err := createOutput(opts.output)
+	if err != nil {
+		return err
+	}
+
+	defer out.Close()
+
+	bw := bufio.NewWriter(out)
+
+	w, err := y4m.NewWriter(bw, header)
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i < v.Frames; i++ {
+		frame := videoFrameLike(src.frames[i])
+		v.Frame(i).ToImage(frame)
+
+		if err := w.WriteFrame(frame); err != nil {
+			return err
+		}
+	}
+
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+
+	return out.Close()
+}
+
+// readTimeSource reads every frame of the input, which can be an animated GIF, a Y4M video, or a
+// pattern for a sequence of images
+func readTimeSource(input string) (*timeSource, error) {
+	var (
+		src *timeSource
+		err error
+	)
+
+	if isSequence(input) {
+		src, err = readSequence(input)
+	} else {
+		src, err = readFrames(input)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(src.frames) < 2 {
+		return nil, fmt.Errorf("%s only has one frame, so there's nothing to sort through time", input)
+	}
+
+	if src.delay == nil {
+		src.delay = make([]int, len(src.frames))
+		for i := range src.delay {
+			src.delay[i] = defaultDelay
+		}
+	}
+
+	return src, nil
+}
+
+// readSequence reads the images that match pattern, in order by name
+func readSequence(pattern string) (*timeSource, error) {
+	files, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no files match %s", pattern)
+	}
+
+	src := &timeSource{}
+
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		frame, format, err := image.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", file, err)
+		}
+
+		src.format = format
+		src.frames = append(src.frames, frame)
+	}
+
+	return src, nil
+}
+
+// readFrames reads every frame of an animated GIF or a Y4M video. Any other image is a single
+// frame.
+func readFrames(input string) (*timeSource, error) {
+	in, err := openInput(input)
+	if err != nil {
+		return nil, err
+	}
+
+	defer in.Close()
+
+	br := bufio.NewReader(in)
+
+	if isY4M(br) {
+		r, err := y4m.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+
+		header := r.Header()
+		src := &timeSource{format: formatY4M, header: &header}
+
+		for {
+			frame, err := r.ReadFrame()
+			if err == io.EOF {
+				break
+			}
+
+			if err != nil {
+				return nil, err
+			}
+
+			src.frames = append(src.frames, frame)
+		}
+
+		if header.FrameRate.Num > 0 && header.FrameRate.Den > 0 {
+			// Many viewers show GIF frames with delays under 2 for much longer (see frameDelay)
+			delay := int(math.Round(100 * float64(header.FrameRate.Den) / float64(header.FrameRate.Num)))
+			if delay < 2 {
+				delay = 2
+			}
+
+			src.delay = make([]int, len(src.frames))
+			for i := range src.delay {
+				src.delay[i] = delay
+			}
+		}
+
+		return src, nil
+	}
+
+	data, err := ioutil.ReadAll(br)
+	if err != nil {
+		return nil, err
+	}
+
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	if format != formatGif {
+		return &timeSource{format: format, frames: []image.Image{img}}, nil
+	}
+
+	anim, err := gif.DecodeAll(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	// Frames are sorted as they're shown, since they can each cover just part of the image
+	src := &timeSource{format: format, delay: anim.Delay, loopCount: anim.LoopCount}
+	for _, frame := range composite(anim) {
+		src.frames = append(src.frames, frame)
+	}
+
+	return src, nil
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pixelCounts counts the colors that the pixel at x,y has over all of the frames
+func pixelCounts(frames []image.Image, x, y int) map[color.Color]int {
+	counts := map[color.Color]int{}
+	for _, frame := range frames {
+		counts[frame.At(x, y)]++
+	}
+
+	return counts
+}
+
+// assertSortedThroughTime makes sure that the red of each pixel only goes down from frame to frame
+func assertSortedThroughTime(t *testing.T, frames []image.Image) {
+	bounds := frames[0].Bounds()
+	for i := 1; i < len(frames); i++ {
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			for x := bounds.Min.X; x < bounds.Max.X; x++ {
+				r0, _, _, _ := frames[i-1].At(x, y).RGBA()
+				r1, _, _, _ := frames[i].At(x, y).RGBA()
+				assert.True(t, r0 >= r1, "frame %d isn't sorted at %d,%d", i, x, y)
+			}
+		}
+	}
+}
+
+func TestRunTimeGIF(t *testing.T) {
+	t.Parallel()
+
+	anim := testAnimation()
+	shown := composite(anim)
+	sorted := runOnAnimation(t, anim, "-time", "-combiner", "red")
+
+	require.Len(t, sorted.Image, len(anim.Image))
+	assert.Equal(t, anim.Delay, sorted.Delay)
+	assert.Equal(t, anim.LoopCount, sorted.LoopCount)
+
+	var in, out []image.Image
+	for i := range shown {
+		in = append(in, shown[i])
+		out = append(out, sorted.Image[i])
+	}
+
+	assertSortedThroughTime(t, out)
+
+	// Each pixel keeps the colors it had over time
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			expected := map[color.NRGBA]int{}
+			for c, n := range pixelCounts(in, x, y) {
+				expected[color.NRGBAModel.Convert(c).(color.NRGBA)] += n
+			}
+
+			actual := map[color.NRGBA]int{}
+			for c, n := range pixelCounts(out, x, y) {
+				actual[color.NRGBAModel.Convert(c).(color.NRGBA)] += n
+			}
+
+			assert.Equal(t, expected, actual, "%d,%d", x, y)
+		}
+	}
+}
+
+func TestRunTimeSequence(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "pixelsort")
+	require.NoError(t, err)
+
+	defer os.RemoveAll(dir)
+
+	// The reds go up from frame to frame, the other way from how they're sorted
+	var frames []image.Image
+	for i := 0; i < 3; i++ {
+		frame := image.NewNRGBA(image.Rect(0, 0, 4, 2))
+		for p := 0; p < len(frame.Pix); p += 4 {
+			copy(frame.Pix[p:], []uint8{uint8(0x40*i + p), 0x10, 0x20, 0xff})
+		}
+
+		frames = append(frames, frame)
+		savePNG(t, filepath.Join(dir, fmt.Sprintf("in%d.png", i)), frame)
+	}
+
+	output := filepath.Join(dir, "out.png")
+
+	opts, err := parseOptions("pixelsort", []string{"-time", "-combiner", "red", filepath.Join(dir, "in*.png"),
+		output}, &bytes.Buffer{})
+	require.NoError(t, err)
+	require.NoError(t, run(opts))
+
+	var sorted []image.Image
+	for i := range frames {
+		sorted = append(sorted, imageFromFile(t, sequenceName(output, i, len(frames))))
+	}
+
+	// The frames come out the other way around
+	for i := range sorted {
+		assert.Equal(t, colorCounts(frames[len(frames)-1-i]), colorCounts(sorted[i]), "frame %d", i)
+	}
+
+	assertSortedThroughTime(t, sorted)
+}
+
+func TestRunTimeVideo(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "pixelsort")
+	require.NoError(t, err)
+
+	defer os.RemoveAll(dir)
+
+	input := filepath.Join(dir, "in.y4m")
+	video := testVideo(4)
+	writeVideo(t, input, video)
+
+	opts, err := parseOptions("pixelsort", []string{"-time", "-combiner", "red", "-mode", "intervals", "-lower",
+		"0.25", "-upper", "0.75", input}, &bytes.Buffer{})
+	require.NoError(t, err)
+	require.NoError(t, run(opts))
+
+	header, sorted := readVideo(t, opts.output)
+	assert.Equal(t, 25, header.FrameRate.Num)
+	require.Len(t, sorted, len(video))
+
+	var in []image.Image
+	for _, frame := range video {
+		in = append(in, frame)
+	}
+
+	// The samples of each pixel are kept exactly, so sorting only moves them through time
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 8; x++ {
+			assert.Equal(t, pixelCounts(in, x, y), pixelCounts(sorted, x, y), "%d,%d", x, y)
+		}
+	}
+}
+
+func TestRunTimeErrors(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "pixelsort")
+	require.NoError(t, err)
+
+	defer os.RemoveAll(dir)
+
+	input := filepath.Join(dir, "in.png")
+	savePNG(t, input, image.NewNRGBA(image.Rect(0, 0, 2, 2)))
+
+	opts, err := parseOptions("pixelsort", []string{"-time", input}, &bytes.Buffer{})
+	require.NoError(t, err)
+	assert.EqualError(t, run(opts), input+" only has one frame, so there's nothing to sort through time")
+
+	savePNG(t, filepath.Join(dir, "in2.png"), image.NewNRGBA(image.Rect(0, 0, 3, 2)))
+
+	opts, err = parseOptions("pixelsort", []string{"-time", filepath.Join(dir, "in*.png"),
+		filepath.Join(dir, "out.png")}, &bytes.Buffer{})
+	require.NoError(t, err)
+	assert.EqualError(t, run(opts), "sortablecolor: frame 1 is (3,2), but the first frame is (2,2)")
+
+	opts, err = parseOptions("pixelsort", []string{"-time", filepath.Join(dir, "*.jpg"),
+		filepath.Join(dir, "out.png")}, &bytes.Buffer{})
+	require.NoError(t, err)
+	assert.EqualError(t, run(opts), "no files match "+filepath.Join(dir, "*.jpg"))
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"io"
+	"os"
+
+	"github.com/dcormier/go-pixelsort/combiner"
+	"github.com/dcormier/go-pixelsort/sortablecolor"
+	"github.com/dcormier/go-pixelsort/y4m"
+)
+
+// isY4M is whether the input is a YUV4MPEG2 stream, without reading any of it
+func isY4M(br *bufio.Reader) bool {
+	magic, err := br.Peek(len(y4m.Magic))
+
+	return err == nil && string(magic) == y4m.Magic
+}
+
+// runY4M sorts a YUV4MPEG2 stream. Every frame is sorted for y4m output; otherwise, only the
+// first frame is read.
+func runY4M(opts *options, br *bufio.Reader) error {
+	r, err := y4m.NewReader(br)
+	if err != nil {
+		return err
+	}
+
+	header := r.Header()
+
+	writeMetadata(opts.input, formatY4M, image.Rect(0, 0, header.Width, header.Height))
+	if header.FrameRate.Den != 0 {
+		fmt.Fprintf(messages, "    Frame rate: %.5g fps\n", float64(header.FrameRate.Num)/float64(header.FrameRate.Den))
+	}
+
+	fmt.Fprintln(messages)
+
+	if opts.format == formatY4M && !opts.animates() {
+		return runVideo(opts, r)
+	}
+
+	frame, err := r.ReadFrame()
+	if err == io.EOF {
+		return fmt.Errorf("%s has no frames", opts.input)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if opts.animates() {
+		fmt.Fprintln(messages, "Only the first frame will be animated")
+	} else {
+		fmt.Fprintf(messages, "Only the first frame will be written; use -format %s to keep them all\n", formatY4M)
+	}
+
+	return sortAndWrite(opts, frame)
+}
+
+// runVideo sorts each frame of a YUV4MPEG2 stream, and writes them out as they're sorted. With
+// -smooth or -hysteresis, the intervals of each frame carry on from the frames before it.
+func runVideo(opts *options, r *y4m.Reader) error {
+	fmt.Fprintf(messages, "Sorting each frame by %v (%s, %s)\n", opts.combiner.Name(), opts.sort.Mode,
+		orderName(opts.sort.Descending))
+	fmt.Fprintf(messages, "Output will be written to: %v\n", opts.output)
+
+	out, err := createOutput(opts.output)
+	if err != nil {
+		return err
+	}
+
+	defer out.Close()
+
+	bw := bufio.NewWriter(out)
+
+	w, err := y4m.NewWriter(bw, r.Header())
+	if err != nil {
+		return err
+	}
+
+	var mask *sortablecolor.TemporalMask
+	if opts.smoothing > 0 || opts.hysteresis > 0 {
+		md := combiner.MetadataOf(opts.combiner)
+
+		mask = &sortablecolor.TemporalMask{
+			Smoothing:  opts.smoothing,
+			Hysteresis: uint64(opts.hysteresis * float64(md.Max-md.Min)),
+		}
+	}
+
+	frames := 0
+	for {
+		frame, err := r.ReadFrame()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+
+		buffer, bounds := sortablecolor.SortableBufferFromImage(frame, opts.combiner)
+
+		sortOpts := opts.sort
+		if mask != nil {
+			sortOpts.Mask = mask.Next(buffer, opts.sort)
+		}
+
+		buffer.Sort(bounds, sortOpts)
+
+		sorted := videoFrameLike(frame)
+		buffer.ToImage(sorted)
+
+		if err := w.WriteFrame(sorted); err != nil {
+			return err
+		}
+
+		frames++
+	}
+
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(messages, "Sorted %d frames\n", frames)
+	fmt.Fprintln(messages)
+
+	return out.Close()
+}
+
+// videoFrameLike gives an image to sort a frame into, which keeps its samples as they are
+func videoFrameLike(frame image.Image) sortablecolor.SettableImage {
+	if _, ok := frame.(*image.Gray); ok {
+		return image.NewGray(frame.Bounds())
+	}
+
+	return y4m.NewImage(frame.Bounds())
+}
+
+// createOutput creates the output file, or gives stdout if it's "-"
+func createOutput(output string) (io.WriteCloser, error) {
+	if output == "-" {
+		return nopWriteCloser{os.Stdout}, nil
+	}
+
+	return os.Create(output)
+}
+
+// nopWriteCloser is an io.Writer with a Close method that does nothing
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dcormier/go-pixelsort/y4m"
+)
+
+// testVideo makes a 4:4:4 video with frames of random colors
+func testVideo(frames int) []*image.YCbCr {
+	rnd := rand.New(rand.NewSource(1))
+
+	video := make([]*image.YCbCr, frames)
+	for i := range video {
+		video[i] = image.NewYCbCr(image.Rect(0, 0, 8, 4), image.YCbCrSubsampleRatio444)
+		rnd.Read(video[i].Y)
+		rnd.Read(video[i].Cb)
+		rnd.Read(video[i].Cr)
+	}
+
+	return video
+}
+
+func writeVideo(t *testing.T, file string, video []*image.YCbCr) {
+	out := &bytes.Buffer{}
+
+	w, err := y4m.NewWriter(out, y4m.Header{Width: 8, Height: 4, FrameRate: y4m.Ratio{Num: 25, Den: 1},
+		Colorspace: y4m.C444})
+	require.NoError(t, err)
+
+	for _, frame := range video {
+		require.NoError(t, w.WriteFrame(frame))
+	}
+
+	require.NoError(t, ioutil.WriteFile(file, out.Bytes(), 0644))
+}
+
+func readVideo(t *testing.T, file string) (y4m.Header, []image.Image) {
+	f, err := os.Open(file)
+	require.NoError(t, err)
+
+	defer f.Close()
+
+	r, err := y4m.NewReader(f)
+	require.NoError(t, err)
+
+	var frames []image.Image
+	for {
+		frame, err := r.ReadFrame()
+		if err == io.EOF {
+			return r.Header(), frames
+		}
+
+		require.NoError(t, err)
+		frames = append(frames, frame)
+	}
+}
+
+// ycbcrCounts counts how many pixels of each color img has, without converting them
+func ycbcrCounts(img image.Image) map[color.Color]int {
+	counts := map[color.Color]int{}
+
+	bounds := img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			counts[img.At(x, y)]++
+		}
+	}
+
+	return counts
+}
+
+func TestRunVideo(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "pixelsort")
+	require.NoError(t, err)
+
+	defer os.RemoveAll(dir)
+
+	input := filepath.Join(dir, "in.y4m")
+	video := testVideo(3)
+	writeVideo(t, input, video)
+
+	opts, err := parseOptions("pixelsort", []string{"-combiner", "red", "-mode", "rows", input}, &bytes.Buffer{})
+	require.NoError(t, err)
+	require.NoError(t, run(opts))
+
+	header, sorted := readVideo(t, opts.output)
+	assert.Equal(t, y4m.Ratio{Num: 25, Den: 1}, header.FrameRate)
+	require.Len(t, sorted, len(video))
+
+	for i, frame := range sorted {
+		// The samples of each pixel are kept exactly, so sorting only moves them around
+		assert.Equal(t, ycbcrCounts(video[i]), ycbcrCounts(frame), "frame %d", i)
+
+		for y := 0; y < 4; y++ {
+			for x := 1; x < 8; x++ {
+				r0, _, _, _ := frame.At(x-1, y).RGBA()
+				r1, _, _, _ := frame.At(x, y).RGBA()
+				assert.True(t, r0 >= r1, "frame %d isn't sorted at %d,%d", i, x, y)
+			}
+		}
+	}
+
+	// Smoothing the intervals over time still sorts every frame
+	opts, err = parseOptions("pixelsort", []string{"-combiner", "red", "-mode", "intervals", "-smooth", "0.5",
+		"-hysteresis", "0.1", input}, &bytes.Buffer{})
+	require.NoError(t, err)
+	require.NoError(t, run(opts))
+
+	_, sorted = readVideo(t, opts.output)
+	require.Len(t, sorted, len(video))
+
+	for i, frame := range sorted {
+		assert.Equal(t, ycbcrCounts(video[i]), ycbcrCounts(frame), "frame %d", i)
+	}
+}
+
+func TestRunVideoFirstFrame(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "pixelsort")
+	require.NoError(t, err)
+
+	defer os.RemoveAll(dir)
+
+	input := filepath.Join(dir, "in.y4m")
+	video := testVideo(2)
+	writeVideo(t, input, video)
+
+	opts, err := parseOptions("pixelsort", []string{"-combiner", "red", input, filepath.Join(dir, "out.png")},
+		&bytes.Buffer{})
+	require.NoError(t, err)
+	require.NoError(t, run(opts))
+
+	sorted := imageFromFile(t, opts.output)
+	assert.Equal(t, colorCounts(video[0]), colorCounts(sorted))
+}
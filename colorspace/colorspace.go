@@ -0,0 +1,87 @@
+// Package colorspace converts between color.Color and a number of other color spaces.
+//
+// Every conversion reads its input through color.Color.RGBA(), so 8-bit and 16-bit sources are
+// both converted at full 16-bit precision. Alpha is un-premultiplied before converting; a fully
+// transparent color converts as black.
+//
+// Each of the color space types implements color.Color itself (as an opaque color), so they can
+// be converted back with any color.Model. For example, color.NRGBA64Model.Convert(lab) gives a
+// 16-bit result and color.NRGBAModel.Convert(lab) gives an 8-bit one. Values outside of the sRGB
+// gamut are clamped.
+package colorspace
+
+import (
+	"image/color"
+	"math"
+)
+
+const cMax = 0xffff
+
+// NRGBA returns the non-alpha-premultiplied channels of c, normalized to [0, 1].
+func NRGBA(c color.Color) (r, g, b, a float64) {
+	r32, g32, b32, a32 := c.RGBA()
+	if a32 == 0 {
+		return 0, 0, 0, 0
+	}
+
+	a = float64(a32)
+
+	return float64(r32) / a, float64(g32) / a, float64(b32) / a, a / cMax
+}
+
+// NRGBA64 returns the non-alpha-premultiplied channels of c. Unlike color.NRGBA64Model.Convert, it
+// always reads c through RGBA(), so a color.NRGBA64 gives the same result as any other color with
+// the same RGBA(), and fully transparent colors give transparent black.
+func NRGBA64(c color.Color) color.NRGBA64 {
+	r, g, b, a := c.RGBA()
+
+	premultiplied := color.RGBA64{R: uint16(r), G: uint16(g), B: uint16(b), A: uint16(a)}
+
+	return color.NRGBA64Model.Convert(premultiplied).(color.NRGBA64)
+}
+
+// Linearize converts a gamma-encoded sRGB channel value in [0, 1] to linear light.
+func Linearize(v float64) float64 {
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+// Delinearize converts a linear light channel value in [0, 1] to gamma-encoded sRGB.
+func Delinearize(v float64) float64 {
+	if v <= 0.0031308 {
+		return v * 12.92
+	}
+
+	return 1.055*math.Pow(v, 1/2.4) - 0.055
+}
+
+// rgba builds the return values of color.Color.RGBA() from normalized, gamma-encoded sRGB
+// channel values, clamping them to [0, 1]. The result is opaque.
+func rgba(r, g, b float64) (uint32, uint32, uint32, uint32) {
+	return to16(r), to16(g), to16(b), cMax
+}
+
+func to16(v float64) uint32 {
+	if v <= 0 || math.IsNaN(v) {
+		return 0
+	}
+
+	if v >= 1 {
+		return cMax
+	}
+
+	return uint32(v*cMax + 0.5)
+}
+
+// hue normalizes an angle in degrees to [0, 360).
+func hue(deg float64) float64 {
+	deg = math.Mod(deg, 360)
+	if deg < 0 {
+		deg += 360
+	}
+
+	return deg
+}
@@ -0,0 +1,210 @@
+package colorspace
+
+import (
+	"fmt"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	red   = color.NRGBA{R: 0xff, A: 0xff}
+	blue  = color.NRGBA{B: 0xff, A: 0xff}
+	white = color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+	black = color.NRGBA{A: 0xff}
+)
+
+func TestNRGBA(t *testing.T) {
+	t.Parallel()
+
+	r, g, b, a := NRGBA(color.NRGBA{R: 0xff, G: 0x80, A: 0x80})
+	assert.InDelta(t, 1, r, 1e-9)
+	assert.InDelta(t, float64(0x80)/0xff, g, 1e-3)
+	assert.InDelta(t, 0, b, 1e-9)
+	assert.InDelta(t, float64(0x80)/0xff, a, 1e-9)
+
+	r, g, b, a = NRGBA(color.Transparent)
+	assert.Equal(t, []float64{0, 0, 0, 0}, []float64{r, g, b, a})
+}
+
+func TestNRGBA64(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, color.NRGBA64{R: 0xffff, G: 0x8080, A: 0xffff}, NRGBA64(color.NRGBA{R: 0xff, G: 0x80, A: 0xff}))
+
+	// Fully transparent colors lose whatever color they had
+	assert.Equal(t, color.NRGBA64{}, NRGBA64(color.NRGBA64{R: 0xffff, G: 0x1234}))
+}
+
+func TestKnownValues(t *testing.T) {
+	t.Parallel()
+
+	const delta = 1e-4
+
+	for _, test := range []struct {
+		name     string
+		c        color.Color
+		convert  func(color.Color) []float64
+		expected []float64
+	}{
+		{"XYZ red", red, xyzOf, []float64{0.4124564, 0.2126729, 0.0193339}},
+		{"XYZ white", white, xyzOf, []float64{D65.X, D65.Y, D65.Z}},
+		{"Lab red", red, labOf, []float64{53.2408, 80.0925, 67.2032}},
+		{"Lab blue", blue, labOf, []float64{32.2970, 79.1875, -107.8602}},
+		{"Lab white", white, labOf, []float64{100, 0, 0}},
+		{"Lab black", black, labOf, []float64{0, 0, 0}},
+		{"LCh red", red, lchOf, []float64{53.2408, 104.5518, 39.9990}},
+		{"OKLab red", red, oklabOf, []float64{0.627955, 0.224863, 0.125846}},
+		{"OKLab blue", blue, oklabOf, []float64{0.452014, -0.032457, -0.311528}},
+		{"OKLab white", white, oklabOf, []float64{1, 0, 0}},
+		{"HSV red", red, hsvOf, []float64{0, 1, 1}},
+		{"HSV spring green", color.NRGBA{G: 0xff, B: 0x80, A: 0xff}, hsvOf, []float64{150.1176, 1, 1}},
+		{"HSV gray", color.Gray{Y: 0x80}, hsvOf, []float64{0, 0, float64(0x80) / 0xff}},
+		{"HSL red", red, hslOf, []float64{0, 1, 0.5}},
+		{"HSL pink", color.NRGBA{R: 0xff, G: 0x80, B: 0x80, A: 0xff}, hslOf, []float64{0, 1, 0.7509804}},
+		{"YCbCr red", red, ycbcrOf, []float64{0.299, 0.331264, 1}},
+		{"YCbCr white", white, ycbcrOf, []float64{1, 0.5, 0.5}},
+		{"YCbCr native", color.YCbCr{Y: 0x40, Cb: 0x80, Cr: 0xc0}, ycbcrOf,
+			[]float64{float64(0x40) / 0xff, float64(0x80) / 0xff, float64(0xc0) / 0xff}},
+	} {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			actual := test.convert(test.c)
+			require.Len(t, actual, len(test.expected))
+
+			for i := range actual {
+				assert.InDelta(t, test.expected[i], actual[i], delta, "component %d", i)
+			}
+		})
+	}
+}
+
+var models = map[string]color.Model{
+	"LinearRGB": LinearRGBModel,
+	"XYZ":       XYZModel,
+	"Lab":       LabModel,
+	"LCh":       LChModel,
+	"OKLab":     OKLabModel,
+	"HSV":       HSVModel,
+	"HSL":       HSLModel,
+	"YCbCr":     YCbCrModel,
+}
+
+// TestRoundTrip8 makes sure that 8-bit colors survive a trip through every color space exactly.
+func TestRoundTrip8(t *testing.T) {
+	t.Parallel()
+
+	for name, model := range models {
+		model := model
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			for r := 0; r <= 0xff; r += 5 {
+				for g := 0; g <= 0xff; g += 5 {
+					for b := 0; b <= 0xff; b += 5 {
+						c := color.NRGBA{R: uint8(r), G: uint8(g), B: uint8(b), A: 0xff}
+						converted := model.Convert(c)
+
+						if actual := color.NRGBAModel.Convert(converted); actual != c {
+							require.Equal(t, c, actual, "via %+v", converted)
+						}
+					}
+				}
+			}
+		})
+	}
+}
+
+// TestRoundTrip16 makes sure that 16-bit colors survive a trip through every color space to
+// within one step.
+func TestRoundTrip16(t *testing.T) {
+	t.Parallel()
+
+	for name, model := range models {
+		model := model
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			for r := 0; r <= 0xffff; r += 0x0ccb {
+				for g := 0; g <= 0xffff; g += 0x0ccb {
+					for b := 0; b <= 0xffff; b += 0x0ccb {
+						c := color.NRGBA64{R: uint16(r), G: uint16(g), B: uint16(b), A: 0xffff}
+						converted := model.Convert(c)
+						actual := color.NRGBA64Model.Convert(converted).(color.NRGBA64)
+
+						msg := fmt.Sprintf("%+v via %+v gave %+v", c, converted, actual)
+						require.InDelta(t, c.R, actual.R, 1, msg)
+						require.InDelta(t, c.G, actual.G, 1, msg)
+						require.InDelta(t, c.B, actual.B, 1, msg)
+						require.Equal(t, c.A, actual.A, msg)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestPremultiplied(t *testing.T) {
+	t.Parallel()
+
+	opaque := LabOf(color.NRGBA{R: 0x20, G: 0x80, B: 0xe0, A: 0xff})
+	translucent := LabOf(color.NRGBA{R: 0x20, G: 0x80, B: 0xe0, A: 0x40})
+
+	assert.InDelta(t, opaque.L, translucent.L, 0.5)
+	assert.InDelta(t, opaque.A, translucent.A, 0.5)
+	assert.InDelta(t, opaque.B, translucent.B, 0.5)
+
+	assert.Equal(t, Lab{}, LabOf(color.Transparent))
+}
+
+func TestOutOfGamut(t *testing.T) {
+	t.Parallel()
+
+	c := color.NRGBA64Model.Convert(Lab{L: 50, A: 200, B: -200}).(color.NRGBA64)
+	assert.Equal(t, uint16(0xffff), c.A)
+
+	c = color.NRGBA64Model.Convert(HSL{H: 720, S: 2, L: 0.5}).(color.NRGBA64)
+	assert.Equal(t, color.NRGBA64{R: 0xffff, A: 0xffff}, c)
+}
+
+func xyzOf(c color.Color) []float64 {
+	v := XYZOf(c)
+	return []float64{v.X, v.Y, v.Z}
+}
+
+func labOf(c color.Color) []float64 {
+	v := LabOf(c)
+	return []float64{v.L, v.A, v.B}
+}
+
+func lchOf(c color.Color) []float64 {
+	v := LChOf(c)
+	return []float64{v.L, v.C, v.H}
+}
+
+func oklabOf(c color.Color) []float64 {
+	v := OKLabOf(c)
+	return []float64{v.L, v.A, v.B}
+}
+
+func hsvOf(c color.Color) []float64 {
+	v := HSVOf(c)
+	return []float64{v.H, v.S, v.V}
+}
+
+func hslOf(c color.Color) []float64 {
+	v := HSLOf(c)
+	return []float64{v.H, v.S, v.L}
+}
+
+func ycbcrOf(c color.Color) []float64 {
+	v := YCbCrOf(c)
+	return []float64{v.Y, v.Cb, v.Cr}
+}
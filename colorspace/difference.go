@@ -0,0 +1,100 @@
+package colorspace
+
+import (
+	"math"
+)
+
+// DeltaE76 returns the CIE 1976 color difference between two colors, which is the Euclidean
+// distance between them in L*a*b*.
+func DeltaE76(c1, c2 Lab) float64 {
+	dl := c1.L - c2.L
+	da := c1.A - c2.A
+	db := c1.B - c2.B
+
+	return math.Sqrt(dl*dl + da*da + db*db)
+}
+
+// DeltaE2000 returns the CIEDE2000 color difference between two colors, following
+// http://www2.ece.rochester.edu/~gsharma/ciede2000/ciede2000noteCRNA.pdf with unity parametric
+// weighting factors.
+func DeltaE2000(c1, c2 Lab) float64 {
+	const pow25To7 = 6103515625 // 25^7
+
+	cBar := (math.Hypot(c1.A, c1.B) + math.Hypot(c2.A, c2.B)) / 2
+	cBar7 := math.Pow(cBar, 7)
+	g := 0.5 * (1 - math.Sqrt(cBar7/(cBar7+pow25To7)))
+
+	a1 := (1 + g) * c1.A
+	a2 := (1 + g) * c2.A
+
+	chroma1 := math.Hypot(a1, c1.B)
+	chroma2 := math.Hypot(a2, c2.B)
+
+	h1 := hueAngle(c1.B, a1)
+	h2 := hueAngle(c2.B, a2)
+
+	dL := c2.L - c1.L
+	dC := chroma2 - chroma1
+
+	var dh float64
+	if chroma1*chroma2 != 0 {
+		dh = h2 - h1
+		if dh > 180 {
+			dh -= 360
+		} else if dh < -180 {
+			dh += 360
+		}
+	}
+
+	dH := 2 * math.Sqrt(chroma1*chroma2) * math.Sin(radians(dh/2))
+
+	lBar := (c1.L + c2.L) / 2
+	cBar = (chroma1 + chroma2) / 2
+
+	var hBar float64
+	switch {
+	case chroma1*chroma2 == 0:
+		hBar = h1 + h2
+	case math.Abs(h1-h2) <= 180:
+		hBar = (h1 + h2) / 2
+	case h1+h2 < 360:
+		hBar = (h1 + h2 + 360) / 2
+	default:
+		hBar = (h1 + h2 - 360) / 2
+	}
+
+	t := 1 -
+		0.17*math.Cos(radians(hBar-30)) +
+		0.24*math.Cos(radians(2*hBar)) +
+		0.32*math.Cos(radians(3*hBar+6)) -
+		0.20*math.Cos(radians(4*hBar-63))
+
+	dTheta := 30 * math.Exp(-math.Pow((hBar-275)/25, 2))
+	cBar7 = math.Pow(cBar, 7)
+	rC := 2 * math.Sqrt(cBar7/(cBar7+pow25To7))
+
+	l50 := (lBar - 50) * (lBar - 50)
+	sL := 1 + 0.015*l50/math.Sqrt(20+l50)
+	sC := 1 + 0.045*cBar
+	sH := 1 + 0.015*cBar*t
+	rT := -math.Sin(radians(2*dTheta)) * rC
+
+	dL /= sL
+	dC /= sC
+	dH /= sH
+
+	return math.Sqrt(dL*dL + dC*dC + dH*dH + rT*dC*dH)
+}
+
+// hueAngle returns the angle of (a, b) in degrees, in [0, 360).
+func hueAngle(b, a float64) float64 {
+	if a == 0 && b == 0 {
+		return 0
+	}
+
+	return hue(math.Atan2(b, a) * 180 / math.Pi)
+}
+
+func radians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
@@ -0,0 +1,40 @@
+package colorspace
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeltaE76(t *testing.T) {
+	t.Parallel()
+
+	assert.InDelta(t, 0, DeltaE76(Lab{50, 10, 10}, Lab{50, 10, 10}), 1e-12)
+	assert.InDelta(t, 13, DeltaE76(Lab{50, 0, 0}, Lab{53, 4, 12}), 1e-12)
+}
+
+// TestDeltaE2000 uses test data from Sharma, Wu and Dalal's "The CIEDE2000 Color-Difference
+// Formula: Implementation Notes, Supplementary Test Data, and Mathematical Observations"
+func TestDeltaE2000(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range []struct {
+		c1, c2   Lab
+		expected float64
+	}{
+		{Lab{50, 2.6772, -79.7751}, Lab{50, 0, -82.7485}, 2.0425},
+		{Lab{50, 3.1571, -77.2803}, Lab{50, 0, -82.7485}, 2.8615},
+		{Lab{50, 0, 0}, Lab{50, -1, 2}, 2.3669},
+		{Lab{50, -1, 2}, Lab{50, 0, 0}, 2.3669},
+		{Lab{50, 2.49, -0.001}, Lab{50, -2.49, 0.0011}, 7.2195},
+		{Lab{50, 2.5, 0}, Lab{50, 3.1736, 0.5854}, 1},
+		{Lab{50, 2.5, 0}, Lab{73, 25, -18}, 27.1492},
+		{Lab{50, 2.5, 0}, Lab{56, -27, -3}, 31.903},
+		{Lab{60.2574, -34.0099, 36.2677}, Lab{60.4626, -34.1751, 39.4387}, 1.2644},
+		{Lab{22.7233, 20.0904, -46.694}, Lab{23.0331, 14.973, -42.5619}, 2.0373},
+		{Lab{90.8027, -2.0831, 1.441}, Lab{91.1528, -1.6435, 0.0447}, 1.4441},
+		{Lab{2.0776, 0.0795, -1.135}, Lab{0.9033, -0.0636, -0.5514}, 0.9082},
+	} {
+		assert.InDelta(t, test.expected, DeltaE2000(test.c1, test.c2), 1e-4, "%+v, %+v", test.c1, test.c2)
+	}
+}
@@ -0,0 +1,53 @@
+package colorspace
+
+import (
+	"fmt"
+	"image/color"
+	"strconv"
+	"strings"
+)
+
+// ParseHex parses a CSS-style hex color such as "#0050ff". The leading '#' is optional, and the
+// short (#rgb, #rgba) and alpha (#rrggbbaa) forms are accepted as well.
+func ParseHex(s string) (color.NRGBA, error) {
+	hex := strings.TrimPrefix(s, "#")
+
+	switch len(hex) {
+	case 3, 4:
+		// Expand each digit: "f80" -> "ff8800"
+		long := make([]byte, 0, len(hex)*2)
+		for i := 0; i < len(hex); i++ {
+			long = append(long, hex[i], hex[i])
+		}
+
+		hex = string(long)
+
+	case 6, 8:
+
+	default:
+		return color.NRGBA{}, fmt.Errorf("invalid hex color %q: expected 3, 4, 6 or 8 hex digits", s)
+	}
+
+	if len(hex) == 6 {
+		hex += "ff"
+	}
+
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return color.NRGBA{}, fmt.Errorf("invalid hex color %q: %v", s, err.(*strconv.NumError).Err)
+	}
+
+	return color.NRGBA{R: uint8(v >> 24), G: uint8(v >> 16), B: uint8(v >> 8), A: uint8(v)}, nil
+}
+
+// FormatHex formats c as a CSS-style hex color, such as "#0050ff". The alpha channel is only
+// included if c isn't opaque.
+func FormatHex(c color.Color) string {
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+
+	if n.A == 0xff {
+		return fmt.Sprintf("#%02x%02x%02x", n.R, n.G, n.B)
+	}
+
+	return fmt.Sprintf("#%02x%02x%02x%02x", n.R, n.G, n.B, n.A)
+}
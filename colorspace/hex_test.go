@@ -0,0 +1,37 @@
+package colorspace
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseHex(t *testing.T) {
+	t.Parallel()
+
+	for s, expected := range map[string]color.NRGBA{
+		"#0050ff":   {R: 0x00, G: 0x50, B: 0xff, A: 0xff},
+		"0050FF":    {R: 0x00, G: 0x50, B: 0xff, A: 0xff},
+		"#f80":      {R: 0xff, G: 0x88, B: 0x00, A: 0xff},
+		"#f808":     {R: 0xff, G: 0x88, B: 0x00, A: 0x88},
+		"#01234567": {R: 0x01, G: 0x23, B: 0x45, A: 0x67},
+	} {
+		actual, err := ParseHex(s)
+		require.NoError(t, err, s)
+		assert.Equal(t, expected, actual, s)
+	}
+
+	for _, s := range []string{"", "#", "#12", "#12345", "#gggggg", "blue"} {
+		_, err := ParseHex(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestFormatHex(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "#0050ff", FormatHex(color.NRGBA{R: 0x00, G: 0x50, B: 0xff, A: 0xff}))
+	assert.Equal(t, "#ff880080", FormatHex(color.NRGBA{R: 0xff, G: 0x88, A: 0x80}))
+}
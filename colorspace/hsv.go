@@ -0,0 +1,120 @@
+package colorspace
+
+import (
+	"image/color"
+	"math"
+)
+
+// HSV is a color in the hue, saturation, value model. H is in degrees, in [0, 360); S and V are
+// in [0, 1]. Grays have a hue of 0.
+type HSV struct {
+	H, S, V float64
+}
+
+// HSVModel converts any color.Color to an HSV.
+var HSVModel = color.ModelFunc(func(c color.Color) color.Color { return HSVOf(c) })
+
+// HSVOf converts c to HSV.
+func HSVOf(c color.Color) HSV {
+	if hsv, ok := c.(HSV); ok {
+		return hsv
+	}
+
+	r, g, b, _ := NRGBA(c)
+	h, max, min := hueOf(r, g, b)
+
+	var s float64
+	if max > 0 {
+		s = (max - min) / max
+	}
+
+	return HSV{H: h, S: s, V: max}
+}
+
+// RGBA implements color.Color.
+func (c HSV) RGBA() (r, g, b, a uint32) {
+	chroma := c.V * c.S
+
+	return fromHue(c.H, chroma, c.V-chroma)
+}
+
+// HSL is a color in the hue, saturation, lightness model. H is in degrees, in [0, 360); S and L
+// are in [0, 1]. Grays have a hue of 0.
+type HSL struct {
+	H, S, L float64
+}
+
+// HSLModel converts any color.Color to an HSL.
+var HSLModel = color.ModelFunc(func(c color.Color) color.Color { return HSLOf(c) })
+
+// HSLOf converts c to HSL.
+func HSLOf(c color.Color) HSL {
+	if hsl, ok := c.(HSL); ok {
+		return hsl
+	}
+
+	r, g, b, _ := NRGBA(c)
+	h, max, min := hueOf(r, g, b)
+	l := (max + min) / 2
+
+	var s float64
+	if d := 1 - math.Abs(2*l-1); d > 0 {
+		s = (max - min) / d
+	}
+
+	return HSL{H: h, S: s, L: l}
+}
+
+// RGBA implements color.Color.
+func (c HSL) RGBA() (r, g, b, a uint32) {
+	chroma := (1 - math.Abs(2*c.L-1)) * c.S
+
+	return fromHue(c.H, chroma, c.L-chroma/2)
+}
+
+// hueOf returns the hue (in degrees) along with the largest and smallest of the channels.
+func hueOf(r, g, b float64) (h, max, min float64) {
+	max = math.Max(r, math.Max(g, b))
+	min = math.Min(r, math.Min(g, b))
+
+	chroma := max - min
+
+	switch {
+	case chroma == 0:
+		h = 0
+	case max == r:
+		h = 60 * math.Mod((g-b)/chroma, 6)
+	case max == g:
+		h = 60 * ((b-r)/chroma + 2)
+	default:
+		h = 60 * ((r-g)/chroma + 4)
+	}
+
+	return hue(h), max, min
+}
+
+// fromHue builds an RGB color from a hue (in degrees), a chroma and the amount to add to every
+// channel to match the lightness.
+func fromHue(h, chroma, m float64) (uint32, uint32, uint32, uint32) {
+	h = hue(h) / 60
+	x := chroma * (1 - math.Abs(math.Mod(h, 2)-1))
+
+	var r, g, b float64
+
+	switch {
+	case h < 1:
+		r, g = chroma, x
+	case h < 2:
+		r, g = x, chroma
+	case h < 3:
+		g, b = chroma, x
+	case h < 4:
+		g, b = x, chroma
+	case h < 5:
+		r, b = x, chroma
+	default:
+		r, b = chroma, x
+	}
+
+	return rgba(r+m, g+m, b+m)
+}
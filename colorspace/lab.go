@@ -0,0 +1,122 @@
+package colorspace
+
+import (
+	"image/color"
+	"math"
+)
+
+// Lab is a color in CIE 1976 L*a*b*, relative to D65. L is in [0, 100]; a and b are roughly in
+// [-128, 128].
+type Lab struct {
+	L, A, B float64
+}
+
+// LabModel converts any color.Color to a Lab.
+var LabModel = color.ModelFunc(func(c color.Color) color.Color { return LabOf(c) })
+
+// LabOf converts c to CIE L*a*b*.
+func LabOf(c color.Color) Lab {
+	switch c := c.(type) {
+	case Lab:
+		return c
+	case LCh:
+		return c.Lab()
+	}
+
+	return XYZOf(c).Lab()
+}
+
+const (
+	labEpsilon = 216.0 / 24389.0
+	labKappa   = 24389.0 / 27.0
+)
+
+func labF(t float64) float64 {
+	if t > labEpsilon {
+		return math.Cbrt(t)
+	}
+
+	return (labKappa*t + 16) / 116
+}
+
+func labFInv(t float64) float64 {
+	if t3 := t * t * t; t3 > labEpsilon {
+		return t3
+	}
+
+	return (116*t - 16) / labKappa
+}
+
+// Lab converts c to CIE L*a*b*.
+func (c XYZ) Lab() Lab {
+	fx := labF(c.X / D65.X)
+	fy := labF(c.Y / D65.Y)
+	fz := labF(c.Z / D65.Z)
+
+	return Lab{
+		L: 116*fy - 16,
+		A: 500 * (fx - fy),
+		B: 200 * (fy - fz),
+	}
+}
+
+// XYZ converts c to CIE XYZ.
+func (c Lab) XYZ() XYZ {
+	fy := (c.L + 16) / 116
+	fx := fy + c.A/500
+	fz := fy - c.B/200
+
+	return XYZ{
+		X: labFInv(fx) * D65.X,
+		Y: labFInv(fy) * D65.Y,
+		Z: labFInv(fz) * D65.Z,
+	}
+}
+
+// LCh converts c to its cylindrical representation.
+func (c Lab) LCh() LCh {
+	return LCh{
+		L: c.L,
+		C: math.Hypot(c.A, c.B),
+		H: hue(math.Atan2(c.B, c.A) * 180 / math.Pi),
+	}
+}
+
+// RGBA implements color.Color.
+func (c Lab) RGBA() (r, g, b, a uint32) {
+	return c.XYZ().RGBA()
+}
+
+// LCh is the cylindrical representation of a Lab color. L is the same as Lab.L, C is the chroma
+// and H is the hue angle in degrees, in [0, 360).
+type LCh struct {
+	L, C, H float64
+}
+
+// LChModel converts any color.Color to an LCh.
+var LChModel = color.ModelFunc(func(c color.Color) color.Color { return LChOf(c) })
+
+// LChOf converts c to CIE LCh(ab).
+func LChOf(c color.Color) LCh {
+	if lch, ok := c.(LCh); ok {
+		return lch
+	}
+
+	return LabOf(c).LCh()
+}
+
+// Lab converts c to its rectangular representation.
+func (c LCh) Lab() Lab {
+	h := c.H * math.Pi / 180
+
+	return Lab{
+		L: c.L,
+		A: c.C * math.Cos(h),
+		B: c.C * math.Sin(h),
+	}
+}
+
+// RGBA implements color.Color.
+func (c LCh) RGBA() (r, g, b, a uint32) {
+	return c.Lab().RGBA()
+}
@@ -0,0 +1,58 @@
+package colorspace
+
+import (
+	"image/color"
+	"math"
+)
+
+// OKLab is a color in Björn Ottosson's Oklab space (https://bottosson.github.io/posts/oklab/).
+// L is in [0, 1]; a and b are roughly in [-0.4, 0.4].
+type OKLab struct {
+	L, A, B float64
+}
+
+// OKLabModel converts any color.Color to an OKLab.
+var OKLabModel = color.ModelFunc(func(c color.Color) color.Color { return OKLabOf(c) })
+
+// OKLabOf converts c to Oklab.
+func OKLabOf(c color.Color) OKLab {
+	if ok, isOK := c.(OKLab); isOK {
+		return ok
+	}
+
+	return LinearRGBOf(c).OKLab()
+}
+
+// OKLab converts c to Oklab.
+func (c LinearRGB) OKLab() OKLab {
+	l := math.Cbrt(0.4122214708*c.R + 0.5363325363*c.G + 0.0514459929*c.B)
+	m := math.Cbrt(0.2119034982*c.R + 0.6806995451*c.G + 0.1073969566*c.B)
+	s := math.Cbrt(0.0883024619*c.R + 0.2817188376*c.G + 0.6299787005*c.B)
+
+	return OKLab{
+		L: 0.2104542553*l + 0.7936177850*m - 0.0040720468*s,
+		A: 1.9779984951*l - 2.4285922050*m + 0.4505937099*s,
+		B: 0.0259040371*l + 0.7827717662*m - 0.8086757660*s,
+	}
+}
+
+// LinearRGB converts c to linear light sRGB. The result may be outside of [0, 1] if c is outside
+// of the sRGB gamut.
+func (c OKLab) LinearRGB() LinearRGB {
+	l := c.L + 0.3963377774*c.A + 0.2158037573*c.B
+	m := c.L - 0.1055613458*c.A - 0.0638541728*c.B
+	s := c.L - 0.0894841775*c.A - 1.2914855480*c.B
+
+	l, m, s = l*l*l, m*m*m, s*s*s
+
+	return LinearRGB{
+		R: 4.0767416621*l - 3.3077115913*m + 0.2309699292*s,
+		G: -1.2684380046*l + 2.6097574011*m - 0.3413193965*s,
+		B: -0.0041960863*l - 0.7034186147*m + 1.7076147010*s,
+	}
+}
+
+// RGBA implements color.Color.
+func (c OKLab) RGBA() (r, g, b, a uint32) {
+	return c.LinearRGB().RGBA()
+}
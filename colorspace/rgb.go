@@ -0,0 +1,29 @@
+package colorspace
+
+import (
+	"image/color"
+)
+
+// LinearRGB is a color in linear light sRGB. Each channel is in [0, 1].
+type LinearRGB struct {
+	R, G, B float64
+}
+
+// LinearRGBModel converts any color.Color to a LinearRGB.
+var LinearRGBModel = color.ModelFunc(func(c color.Color) color.Color { return LinearRGBOf(c) })
+
+// LinearRGBOf converts c to linear light sRGB.
+func LinearRGBOf(c color.Color) LinearRGB {
+	if lin, ok := c.(LinearRGB); ok {
+		return lin
+	}
+
+	r, g, b, _ := NRGBA(c)
+
+	return LinearRGB{R: Linearize(r), G: Linearize(g), B: Linearize(b)}
+}
+
+// RGBA implements color.Color.
+func (c LinearRGB) RGBA() (r, g, b, a uint32) {
+	return rgba(Delinearize(c.R), Delinearize(c.G), Delinearize(c.B))
+}
@@ -0,0 +1,61 @@
+package colorspace
+
+import (
+	"image/color"
+)
+
+// D65 is the CIE XYZ tristimulus value of the D65 white point used by sRGB, with Y normalized to 1.
+var D65 = XYZ{X: 0.95047, Y: 1, Z: 1.08883}
+
+// XYZ is a color in CIE 1931 XYZ, relative to D65 with Y in [0, 1].
+type XYZ struct {
+	X, Y, Z float64
+}
+
+// XYZModel converts any color.Color to an XYZ.
+var XYZModel = color.ModelFunc(func(c color.Color) color.Color { return XYZOf(c) })
+
+// XYZOf converts c to CIE XYZ.
+func XYZOf(c color.Color) XYZ {
+	if xyz, ok := c.(XYZ); ok {
+		return xyz
+	}
+
+	return LinearRGBOf(c).XYZ()
+}
+
+// XYZ converts c to CIE XYZ.
+func (c LinearRGB) XYZ() XYZ {
+	return XYZ{
+		X: 0.4124564*c.R + 0.3575761*c.G + 0.1804375*c.B,
+		Y: 0.2126729*c.R + 0.7151522*c.G + 0.0721750*c.B,
+		Z: 0.0193339*c.R + 0.1191920*c.G + 0.9503041*c.B,
+	}
+}
+
+// LinearRGB converts c to linear light sRGB. The result may be outside of [0, 1] if c is outside
+// of the sRGB gamut.
+func (c XYZ) LinearRGB() LinearRGB {
+	return LinearRGB{
+		R: 3.2404542*c.X - 1.5371385*c.Y - 0.4985314*c.Z,
+		G: -0.9692660*c.X + 1.8760108*c.Y + 0.0415560*c.Z,
+		B: 0.0556434*c.X - 0.2040259*c.Y + 1.0572252*c.Z,
+	}
+}
+
+// Chromaticity returns the CIE 1931 xy chromaticity coordinates of c. Black has no chromaticity,
+// so it gives back the chromaticity of D65.
+func (c XYZ) Chromaticity() (x, y float64) {
+	sum := c.X + c.Y + c.Z
+	if sum <= 0 {
+		c = D65
+		sum = c.X + c.Y + c.Z
+	}
+
+	return c.X / sum, c.Y / sum
+}
+
+// RGBA implements color.Color.
+func (c XYZ) RGBA() (r, g, b, a uint32) {
+	return c.LinearRGB().RGBA()
+}
@@ -0,0 +1,55 @@
+package colorspace
+
+import (
+	"image/color"
+)
+
+// YCbCr is a color in full range (JFIF) Y'CbCr, as used by image/color, but with floating point
+// precision. Each component is in [0, 1]; Cb and Cr are centered on 0.5.
+type YCbCr struct {
+	Y, Cb, Cr float64
+}
+
+// YCbCrModel converts any color.Color to a YCbCr.
+var YCbCrModel = color.ModelFunc(func(c color.Color) color.Color { return YCbCrOf(c) })
+
+// ITU-R BT.601 luma coefficients.
+const (
+	kr = 0.299
+	kb = 0.114
+	kg = 1 - kr - kb
+)
+
+// YCbCrOf converts c to Y'CbCr. If c is already a color.YCbCr or color.NYCbCrA, its components
+// are used directly rather than round tripping them through RGB.
+func YCbCrOf(c color.Color) YCbCr {
+	switch c := c.(type) {
+	case YCbCr:
+		return c
+	case color.YCbCr:
+		return YCbCr{Y: float64(c.Y) / 0xff, Cb: float64(c.Cb) / 0xff, Cr: float64(c.Cr) / 0xff}
+	case color.NYCbCrA:
+		return YCbCrOf(c.YCbCr)
+	}
+
+	r, g, b, _ := NRGBA(c)
+	y := kr*r + kg*g + kb*b
+
+	return YCbCr{
+		Y:  y,
+		Cb: (b-y)/(2*(1-kb)) + 0.5,
+		Cr: (r-y)/(2*(1-kr)) + 0.5,
+	}
+}
+
+// RGBA implements color.Color.
+func (c YCbCr) RGBA() (r, g, b, a uint32) {
+	cb := c.Cb - 0.5
+	cr := c.Cr - 0.5
+
+	rf := c.Y + 2*(1-kr)*cr
+	bf := c.Y + 2*(1-kb)*cb
+	gf := (c.Y - kr*rf - kb*bf) / kg
+
+	return rgba(rf, gf, bf)
+}
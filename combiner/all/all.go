@@ -1,4 +1,5 @@
-// Package all simple imports all the combiners so that they're all registered
+// Package all simple imports all the combiners so that they're all registered with
+// combiner.Register
 package all
 
 import (
@@ -6,13 +7,29 @@ import (
 	"github.com/dcormier/go-pixelsort/combiner"
 	"github.com/dcormier/go-pixelsort/combiner/alphablend"
 	"github.com/dcormier/go-pixelsort/combiner/basic"
+	_ "github.com/dcormier/go-pixelsort/combiner/cache"
+	"github.com/dcormier/go-pixelsort/combiner/channel"
+	"github.com/dcormier/go-pixelsort/combiner/cvd"
+	_ "github.com/dcormier/go-pixelsort/combiner/decorate"
+	_ "github.com/dcormier/go-pixelsort/combiner/distance"
+	_ "github.com/dcormier/go-pixelsort/combiner/expr"
+	"github.com/dcormier/go-pixelsort/combiner/gradient"
+	"github.com/dcormier/go-pixelsort/combiner/hue"
+	"github.com/dcormier/go-pixelsort/combiner/localcontrast"
+	"github.com/dcormier/go-pixelsort/combiner/noise"
+	_ "github.com/dcormier/go-pixelsort/combiner/palette"
 	"github.com/dcormier/go-pixelsort/combiner/perceivedoption1"
 	"github.com/dcormier/go-pixelsort/combiner/perceivedoption2"
 	"github.com/dcormier/go-pixelsort/combiner/perceivedoption2noalpha"
+	"github.com/dcormier/go-pixelsort/combiner/radial"
+	"github.com/dcormier/go-pixelsort/combiner/spacecurve"
 	"github.com/dcormier/go-pixelsort/combiner/standardobjective"
+	"github.com/dcormier/go-pixelsort/combiner/temperature"
+	"github.com/dcormier/go-pixelsort/combiner/ycbcr"
 )
 
-// All retuns all the known combiner.Combiners
+// All retuns all the known combiner.Combiners. Combiners that have to be configured before they
+// can be used (such as distance, expr, cache and the decorators) are registered, but not included.
 func All() []combiner.Combiner {
 	return []combiner.Combiner{
 		alphablend.Combiner,
@@ -21,5 +38,27 @@ func All() []combiner.Combiner {
 		perceivedoption2.Combiner,
 		perceivedoption2noalpha.Combiner,
 		standardobjective.Combiner,
+		channel.Red,
+		channel.Green,
+		channel.Blue,
+		channel.Alpha,
+		channel.Min,
+		channel.Max,
+		ycbcr.Y,
+		ycbcr.Cb,
+		ycbcr.Cr,
+		spacecurve.HilbertRGB,
+		spacecurve.HilbertLab,
+		spacecurve.MortonRGB,
+		spacecurve.MortonLab,
+		hue.Combiner,
+		localcontrast.Combiner,
+		gradient.Combiner,
+		radial.Combiner,
+		noise.Combiner,
+		temperature.Combiner,
+		cvd.ProtanopiaCombiner,
+		cvd.DeuteranopiaCombiner,
+		cvd.TritanopiaCombiner,
 	}
 }
@@ -20,6 +20,11 @@ import (
 // CMax is the maximum value of a color (as used for alpha blending)
 const CMax uint32 = math.MaxUint8
 
+// AlphaBlendMax is the largest value that AlphaBlend gives for the channel values of a color.Color
+// blended with a white (CMax) background. Per the TODO above, dark, translucent colors can blend to
+// below zero, so combiners should use ClampBlended to keep their values in [0, AlphaBlendMax].
+const AlphaBlendMax = 0xffffff
+
 // AlphaBlend helps convert RGBA color values to RGB
 func AlphaBlend(channelValue, alphaValue, backgroundValue uint32) (blended float64) {
 	// http://stackoverflow.com/questions/2049230/convert-rgba-color-to-rgb
@@ -31,3 +36,17 @@ func AlphaBlend(channelValue, alphaValue, backgroundValue uint32) (blended float
 
 	return
 }
+
+// ClampBlended converts a value computed from AlphaBlend results to a combiner value in [0, max].
+// Values that blended to below zero become 0, rather than wrapping around to huge values.
+func ClampBlended(blended float64, max uint64) uint64 {
+	if blended <= 0 {
+		return 0
+	}
+
+	if blended >= float64(max) {
+		return max
+	}
+
+	return uint64(blended)
+}
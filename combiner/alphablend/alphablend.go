@@ -1,34 +1,67 @@
 // Package alphablend implements combiner.Combiner using
 // http://stackoverflow.com/a/3968341/297468
-// Assumes a white background to blend with.
+// Assumes a white background to blend with, unless another is given to NewWithBackground.
 package alphablend
 
 import (
 	"image/color"
 
+	"github.com/dcormier/go-pixelsort/colorspace"
 	"github.com/dcormier/go-pixelsort/combiner"
 )
 
 // Combiner is an instance of this Combiner
 var Combiner = New()
 
+func init() {
+	combiner.Register("alphablend", func(p *combiner.Params) (combiner.Combiner, error) {
+		return NewWithBackground(p.Color("bg", color.White)), nil
+	})
+}
+
 var _ combiner.Combiner = (*alphaBlend)(nil)
 
-type alphaBlend struct{}
+type alphaBlend struct {
+	bg color.NRGBA
+}
 
 // New creates a new combiner.Combiner that uses alpha blending (assumes a white background)
 func New() combiner.Combiner {
-	return &alphaBlend{}
+	return NewWithBackground(color.White)
+}
+
+// NewWithBackground creates a new combiner.Combiner that uses alpha blending with the given
+// background color
+func NewWithBackground(bg color.Color) combiner.Combiner {
+	return &alphaBlend{bg: color.NRGBAModel.Convert(bg).(color.NRGBA)}
+}
+
+func (ab *alphaBlend) Name() string {
+	if ab.bg == color.NRGBAModel.Convert(color.White) {
+		return "alpha blend"
+	}
+
+	return "alpha blend (over " + colorspace.FormatHex(ab.bg) + ")"
+}
+
+func (ab *alphaBlend) Metadata() combiner.Metadata {
+	return combiner.Metadata{
+		Description: "Luma, after alpha blending with a " + colorspace.FormatHex(ab.bg) + " background",
+		Max:         ab.max(),
+	}
 }
 
-func (*alphaBlend) Name() string {
-	return "alpha blend"
+// max gives the largest value for any color, which is the value for opaque white
+func (ab *alphaBlend) max() uint64 {
+	return uint64(combiner.AlphaBlend(0xffff, 0xffff, uint32(ab.bg.R))*0.3 +
+		combiner.AlphaBlend(0xffff, 0xffff, uint32(ab.bg.G))*0.59 +
+		combiner.AlphaBlend(0xffff, 0xffff, uint32(ab.bg.B))*0.11)
 }
 
-func (*alphaBlend) Combine(c color.Color) uint64 {
+func (ab *alphaBlend) Combine(c color.Color) uint64 {
 	r, g, b, a := c.RGBA()
 
-	return uint64(combiner.AlphaBlend(r, a, combiner.CMax)*0.3 +
-		combiner.AlphaBlend(g, a, combiner.CMax)*0.59 +
-		combiner.AlphaBlend(b, a, combiner.CMax)*0.11)
+	return combiner.ClampBlended(combiner.AlphaBlend(r, a, uint32(ab.bg.R))*0.3+
+		combiner.AlphaBlend(g, a, uint32(ab.bg.G))*0.59+
+		combiner.AlphaBlend(b, a, uint32(ab.bg.B))*0.11, ab.max())
 }
@@ -0,0 +1,48 @@
+package alphablend
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dcormier/go-pixelsort/combiner/combinertest"
+	"github.com/dcormier/go-pixelsort/sortablecolor"
+)
+
+func TestConformance(t *testing.T) {
+	t.Parallel()
+
+	t.Run(Combiner.Name(), func(t *testing.T) {
+		combinertest.Run(t, Combiner)
+	})
+
+	black := NewWithBackground(color.Black)
+	t.Run(black.Name(), func(t *testing.T) {
+		combinertest.Run(t, black)
+	})
+}
+
+func TestTranslucentOrder(t *testing.T) {
+	t.Parallel()
+
+	white := color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+	gray := color.NRGBA{R: 0x80, G: 0x80, B: 0x80, A: 0xff}
+	dark := color.NRGBA{A: 0x80}
+
+	img := image.NewNRGBA(image.Rect(0, 0, 3, 1))
+	img.SetNRGBA(0, 0, white)
+	img.SetNRGBA(1, 0, dark)
+	img.SetNRGBA(2, 0, gray)
+
+	// The black, translucent pixel blends to below zero, which is the darkest there is, so it sorts
+	// first (rather than wrapping around to sort after white)
+	buffer, bounds := sortablecolor.SortableBufferFromImage(img, Combiner)
+	buffer.Sort(bounds, sortablecolor.Options{Mode: sortablecolor.Global})
+
+	sorted := image.NewNRGBA(bounds)
+	buffer.ToImage(sorted)
+
+	assert.Equal(t, []color.Color{dark, gray, white}, []color.Color{sorted.At(0, 0), sorted.At(1, 0), sorted.At(2, 0)})
+}
@@ -0,0 +1,20 @@
+package combiner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClampBlended(t *testing.T) {
+	t.Parallel()
+
+	// A dark, translucent color blends to below zero
+	dark := AlphaBlend(0x10, 0x8000, CMax)
+	assert.True(t, dark < 0)
+	assert.Equal(t, uint64(0), ClampBlended(dark, AlphaBlendMax))
+
+	assert.Equal(t, uint64(AlphaBlendMax), ClampBlended(AlphaBlend(0xffff, 0xffff, CMax), AlphaBlendMax))
+	assert.Equal(t, uint64(AlphaBlendMax), ClampBlended(AlphaBlendMax+0.5, AlphaBlendMax))
+	assert.Equal(t, uint64(1234), ClampBlended(1234.7, AlphaBlendMax))
+}
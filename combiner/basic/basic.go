@@ -1,4 +1,5 @@
 // Package basic implements Combiner using the most basic of methods. Not a good visual sort.
+// See package spacecurve for a multi-dimensional sort that keeps similar colors together.
 package basic
 
 import (
@@ -10,6 +11,10 @@ import (
 // Combiner is an instance of this Combiner
 var Combiner = New()
 
+func init() {
+	combiner.Register("basic", combiner.Static(Combiner))
+}
+
 var _ combiner.Combiner = (*basic)(nil)
 
 type basic struct{}
@@ -23,6 +28,14 @@ func (*basic) Name() string {
 	return "basic"
 }
 
+func (*basic) Metadata() combiner.Metadata {
+	return combiner.Metadata{
+		Description: "The product of all of the channels",
+		Min:         1,
+		Max:         0xffff * 0xffff * 0xffff * 0xffff,
+	}
+}
+
 func (*basic) Combine(c color.Color) uint64 {
 	r, g, b, a := c.RGBA()
 
@@ -0,0 +1,124 @@
+// Package cache wraps a combiner.Combiner with a memoizing cache, so that it only has to combine
+// each distinct color once. Images with few distinct colors (such as paletted GIFs and flat
+// illustrations) sort much faster with expensive combiners this way.
+//
+// Colors are cached by the value of color.Color.RGBA(), so the wrapped combiner must give the same
+// value for any two colors with the same RGBA() (all of the built-in combiners do, apart from the
+// very small differences that the ycbcr combiners have for YCbCr sources).
+package cache
+
+import (
+	"image/color"
+	"sync"
+
+	"github.com/dcormier/go-pixelsort/combiner"
+)
+
+// DefaultSize is the default maximum number of colors to cache
+const DefaultSize = 1 << 16
+
+// shardCount is the most independently locked parts that a cache is split into. It's a power of
+// two.
+const shardCount = 64
+
+func init() {
+	combiner.Register("cache", func(p *combiner.Params) (combiner.Combiner, error) {
+		inner := p.Combiner("of", nil)
+		size := p.IntIn("size", DefaultSize, 1, 1<<30)
+		if p.Err() != nil {
+			return nil, p.Err()
+		}
+
+		return New(inner, size), nil
+	})
+}
+
+var _ combiner.Combiner = (*cache)(nil)
+
+type cache struct {
+	inner  combiner.Combiner
+	shards []shard
+
+	// shift picks a shard from the top bits of a mixed key; there are 1<<(64-shift) shards
+	shift uint
+}
+
+type shard struct {
+	sync.RWMutex
+
+	values map[uint64]uint64
+	max    int
+}
+
+// New wraps c with a cache that holds at most size colors. It's safe to use the returned
+// combiner.Combiner from multiple goroutines at once, as long as c is. Once the cache is full,
+// arbitrary colors are evicted to make room.
+//
+// A combiner.ImageCombiner can give different values for the same color, so it's returned as it
+// is, without a cache. So is c if size is less than 1.
+func New(c combiner.Combiner, size int) combiner.Combiner {
+	if _, ok := c.(combiner.ImageCombiner); ok || size < 1 {
+		return c
+	}
+
+	// Every shard needs room for at least one color
+	count, shift := shardCount, uint(58)
+	for count > size {
+		count /= 2
+		shift++
+	}
+
+	cmb := &cache{inner: c, shards: make([]shard, count), shift: shift}
+
+	// Split size exactly, with the first size%count shards holding one more color than the rest
+	for i := range cmb.shards {
+		cmb.shards[i].values = make(map[uint64]uint64)
+		cmb.shards[i].max = size / count
+		if i < size%count {
+			cmb.shards[i].max++
+		}
+	}
+
+	return cmb
+}
+
+// Name is the same as the wrapped combiner's, since the cache doesn't change its values
+func (c *cache) Name() string {
+	return c.inner.Name()
+}
+
+func (c *cache) Metadata() combiner.Metadata {
+	return combiner.MetadataOf(c.inner)
+}
+
+func (c *cache) Combine(clr color.Color) uint64 {
+	r, g, b, a := clr.RGBA()
+	key := uint64(r)<<48 | uint64(g)<<32 | uint64(b)<<16 | uint64(a)
+
+	// Mix the key up a bit so that similar colors are spread across the shards
+	s := &c.shards[(key*0x9e3779b97f4a7c15)>>c.shift]
+
+	s.RLock()
+	v, ok := s.values[key]
+	s.RUnlock()
+
+	if ok {
+		return v
+	}
+
+	v = c.inner.Combine(clr)
+
+	s.Lock()
+	if len(s.values) >= s.max {
+		// Map iteration order is unspecified, so this evicts an arbitrary color
+		for evict := range s.values {
+			delete(s.values, evict)
+			break
+		}
+	}
+
+	s.values[key] = v
+	s.Unlock()
+
+	return v
+}
@@ -0,0 +1,121 @@
+package cache
+
+import (
+	"image/color"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dcormier/go-pixelsort/combiner"
+	"github.com/dcormier/go-pixelsort/combiner/channel"
+	"github.com/dcormier/go-pixelsort/combiner/combinertest"
+	"github.com/dcormier/go-pixelsort/combiner/radial"
+	"github.com/dcormier/go-pixelsort/combiner/spacecurve"
+)
+
+// counter counts how many times it's asked to combine a color
+type counter struct {
+	calls int64
+}
+
+func (*counter) Name() string {
+	return "counter"
+}
+
+func (c *counter) Combine(clr color.Color) uint64 {
+	atomic.AddInt64(&c.calls, 1)
+	return channel.Red.Combine(clr)
+}
+
+func TestCachesOncePerColor(t *testing.T) {
+	t.Parallel()
+
+	inner := &counter{}
+	cmb := New(inner, DefaultSize)
+
+	for i := 0; i < 10; i++ {
+		for r := 0; r < 100; r++ {
+			c := color.NRGBA{R: uint8(r), A: 0xff}
+			require.Equal(t, channel.Red.Combine(c), cmb.Combine(c))
+		}
+	}
+
+	assert.Equal(t, int64(100), inner.calls)
+	assert.Equal(t, "counter", cmb.Name())
+}
+
+func TestBounded(t *testing.T) {
+	t.Parallel()
+
+	for _, size := range []int{1, 3, 63, 64, 100, 128} {
+		cmb := New(&counter{}, size).(*cache)
+
+		for r := 0; r < 0x100; r++ {
+			for g := 0; g < 0x100; g++ {
+				cmb.Combine(color.NRGBA{R: uint8(r), G: uint8(g), A: 0xff})
+			}
+		}
+
+		total := 0
+		for i := range cmb.shards {
+			total += len(cmb.shards[i].values)
+		}
+
+		assert.True(t, total <= size, "%d colors cached, with a size of %d", total, size)
+		assert.True(t, total > 0, "nothing cached, with a size of %d", size)
+	}
+}
+
+func TestConcurrent(t *testing.T) {
+	t.Parallel()
+
+	inner := &counter{}
+	cmb := New(inner, 64)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for j := 0; j < 10000; j++ {
+				c := color.NRGBA{R: uint8(j), G: uint8(j >> 8), A: 0xff}
+				if cmb.Combine(c) != channel.Red.Combine(c) {
+					t.Errorf("wrong value for %v", c)
+					return
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+}
+
+func TestPassThrough(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, radial.Combiner, New(radial.Combiner, DefaultSize))
+	assert.Equal(t, combiner.MetadataOf(channel.Red), combiner.MetadataOf(New(channel.Red, 10)))
+
+	cmb, err := combiner.Parse("cache?of=red&size=10")
+	require.NoError(t, err)
+	assert.Equal(t, "red", cmb.Name())
+}
+
+func TestConformance(t *testing.T) {
+	t.Parallel()
+
+	for _, cmb := range []combiner.Combiner{
+		New(channel.Red, DefaultSize),
+		New(spacecurve.HilbertLab, 16),
+	} {
+		cmb := cmb
+		t.Run(cmb.Name(), func(t *testing.T) {
+			combinertest.Run(t, cmb)
+		})
+	}
+}
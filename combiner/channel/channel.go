@@ -0,0 +1,94 @@
+// Package channel implements combiner.Combiner by keying on a single channel of a color, or on the
+// smallest or largest of its red, green and blue channels.
+// Channels are read without alpha-premultiplication and keep their full 16-bit precision.
+package channel
+
+import (
+	"image/color"
+
+	"github.com/dcormier/go-pixelsort/colorspace"
+	"github.com/dcormier/go-pixelsort/combiner"
+)
+
+var (
+	// Red keys on the red channel
+	Red = newChannel("red", "The red channel", false, func(c color.NRGBA64) uint16 { return c.R })
+
+	// Green keys on the green channel
+	Green = newChannel("green", "The green channel", false, func(c color.NRGBA64) uint16 { return c.G })
+
+	// Blue keys on the blue channel
+	Blue = newChannel("blue", "The blue channel", false, func(c color.NRGBA64) uint16 { return c.B })
+
+	// Alpha keys on the alpha channel
+	Alpha = newChannel("alpha", "The alpha channel", true, func(c color.NRGBA64) uint16 { return c.A })
+
+	// Min keys on whichever of the red, green and blue channels is smallest
+	Min = newChannel("min channel", "The smallest of the red, green and blue channels", false,
+		func(c color.NRGBA64) uint16 { return min(c.R, min(c.G, c.B)) })
+
+	// Max keys on whichever of the red, green and blue channels is largest
+	Max = newChannel("max channel", "The largest of the red, green and blue channels", false,
+		func(c color.NRGBA64) uint16 { return max(c.R, max(c.G, c.B)) })
+)
+
+func init() {
+	combiner.Register("red", combiner.Static(Red))
+	combiner.Register("green", combiner.Static(Green))
+	combiner.Register("blue", combiner.Static(Blue))
+	combiner.Register("alpha", combiner.Static(Alpha))
+	combiner.Register("minchannel", combiner.Static(Min))
+	combiner.Register("maxchannel", combiner.Static(Max))
+}
+
+var _ combiner.Combiner = (*channel)(nil)
+
+type channel struct {
+	name        string
+	description string
+	usesAlpha   bool
+	key         func(color.NRGBA64) uint16
+}
+
+// New creates a combiner.Combiner with the given name and description that uses key to pick a
+// channel value
+func New(name, description string, key func(color.NRGBA64) uint16) combiner.Combiner {
+	// There's no telling whether key looks at alpha
+	return newChannel(name, description, true, key)
+}
+
+func newChannel(name, description string, usesAlpha bool, key func(color.NRGBA64) uint16) combiner.Combiner {
+	return &channel{name: name, description: description, usesAlpha: usesAlpha, key: key}
+}
+
+func (ch *channel) Name() string {
+	return ch.name
+}
+
+func (ch *channel) Metadata() combiner.Metadata {
+	return combiner.Metadata{
+		Description:  ch.description,
+		Max:          0xffff,
+		IgnoresAlpha: !ch.usesAlpha,
+	}
+}
+
+func (ch *channel) Combine(c color.Color) uint64 {
+	return uint64(ch.key(colorspace.NRGBA64(c)))
+}
+
+func min(a, b uint16) uint16 {
+	if a < b {
+		return a
+	}
+
+	return b
+}
+
+func max(a, b uint16) uint16 {
+	if a > b {
+		return a
+	}
+
+	return b
+}
@@ -0,0 +1,26 @@
+package channel
+
+import (
+	"testing"
+
+	"github.com/dcormier/go-pixelsort/combiner"
+	"github.com/dcormier/go-pixelsort/combiner/combinertest"
+)
+
+func TestConformance(t *testing.T) {
+	t.Parallel()
+
+	for _, cmb := range []combiner.Combiner{
+		Red,
+		Green,
+		Blue,
+		Alpha,
+		Min,
+		Max,
+	} {
+		cmb := cmb
+		t.Run(cmb.Name(), func(t *testing.T) {
+			combinertest.Run(t, cmb)
+		})
+	}
+}
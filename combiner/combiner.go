@@ -1,6 +1,7 @@
 package combiner
 
 import (
+	"image"
 	"image/color"
 )
 
@@ -9,3 +10,13 @@ type Combiner interface {
 	Name() string
 	Combine(c color.Color) uint64
 }
+
+// ImageCombiner is a Combiner whose values can depend on where a pixel is in its image, or on the
+// pixels around it. Combine is still used for a color on its own, without an image.
+type ImageCombiner interface {
+	Combiner
+
+	// CombineAt combines the channels of the pixel at (x, y) in img into a numerically sortable
+	// value.
+	CombineAt(img image.Image, x, y int) uint64
+}
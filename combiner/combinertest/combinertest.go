@@ -0,0 +1,307 @@
+// Package combinertest checks that a combiner.Combiner behaves the way the rest of pixelsort
+// expects it to. Any Combiner, built in or not, can be checked from its own tests:
+//
+//	func TestConformance(t *testing.T) {
+//		combinertest.Run(t, mycombiner.New())
+//	}
+package combinertest
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"math/rand"
+	"sync"
+	"testing"
+
+	"github.com/dcormier/go-pixelsort/combiner"
+)
+
+// Options changes which checks Run makes.
+type Options struct {
+	// AlphaTolerance is how far apart (as a fraction of the combiner's range) the values for colors
+	// that differ only in alpha can be, for a combiner whose Metadata says it ignores alpha. The
+	// default is DefaultAlphaTolerance.
+	AlphaTolerance float64
+}
+
+// DefaultAlphaTolerance is the default Options.AlphaTolerance. Alpha-premultiplication costs a
+// little precision, so values can be a little apart.
+const DefaultAlphaTolerance = 1e-3
+
+// Run checks c using the default Options. See RunWithOptions.
+func Run(t *testing.T, c combiner.Combiner) {
+	RunWithOptions(t, c, Options{})
+}
+
+// RunWithOptions checks, as subtests of t, that c:
+//
+//   - gives the same value every time for the same color
+//   - gives the same values when it's used from many goroutines at once
+//   - gives the same value for every fully transparent color, and copes with opaque extremes
+//   - gives the same value for a color whether it has 8 or 16 bits per channel
+//   - only gives values within the range declared by its Metadata (see combiner.MetadataOf)
+//   - gives (nearly) the same value for colors that differ only in alpha, if it says that it
+//     ignores alpha
+//
+// If c is a combiner.ImageCombiner, CombineAt is checked for the first three too.
+func RunWithOptions(t *testing.T, c combiner.Combiner, opts Options) {
+	if opts.AlphaTolerance == 0 {
+		opts.AlphaTolerance = DefaultAlphaTolerance
+	}
+
+	md := combiner.MetadataOf(c)
+
+	t.Run("Deterministic", func(t *testing.T) {
+		for _, clr := range samples() {
+			if first, second := c.Combine(clr), c.Combine(clr); first != second {
+				t.Errorf("%s gave %d, then %d, for %v", c.Name(), first, second, clr)
+			}
+		}
+	})
+
+	t.Run("Concurrent", func(t *testing.T) {
+		colors := samples()
+		expected := make([]uint64, len(colors))
+		for i, clr := range colors {
+			expected[i] = c.Combine(clr)
+		}
+
+		for _, clr := range concurrently(len(colors), func(i int) uint64 { return c.Combine(colors[i]) }, expected) {
+			t.Errorf("%s gave a different value for %v when used concurrently", c.Name(), colors[clr])
+		}
+	})
+
+	t.Run("Extremes", func(t *testing.T) {
+		transparent := []color.Color{
+			color.Transparent,
+			color.NRGBA{R: 0xff, G: 0xff, B: 0xff},
+			color.NRGBA{R: 0xff, G: 0x80},
+			color.NRGBA64{R: 0xffff, G: 0xffff, B: 0xffff},
+			color.RGBA64{},
+			color.Alpha{},
+		}
+
+		expected := c.Combine(transparent[0])
+		for _, clr := range transparent[1:] {
+			if actual := c.Combine(clr); actual != expected {
+				t.Errorf("%s gave %d for %#v, but %d for %#v; both are fully transparent",
+					c.Name(), actual, clr, expected, transparent[0])
+			}
+		}
+
+		for _, clr := range []color.Color{
+			color.Black,
+			color.White,
+			color.Opaque,
+			color.NRGBA{R: 0xff, A: 0xff},
+			color.NRGBA{G: 0xff, A: 0xff},
+			color.NRGBA{B: 0xff, A: 0xff},
+			color.NRGBA64{R: 1, G: 1, B: 1, A: 1},
+		} {
+			// Mostly a check that nothing panics; the range is checked below
+			c.Combine(clr)
+		}
+	})
+
+	t.Run("BitDepth", func(t *testing.T) {
+		for _, clr := range samples8() {
+			premultiplied := color.RGBAModel.Convert(clr).(color.RGBA)
+
+			for _, pair := range [][2]color.Color{
+				{clr, color.NRGBA64{
+					R: uint16(clr.R) * 0x101, G: uint16(clr.G) * 0x101, B: uint16(clr.B) * 0x101, A: uint16(clr.A) * 0x101,
+				}},
+				{premultiplied, color.RGBA64{
+					R: uint16(premultiplied.R) * 0x101, G: uint16(premultiplied.G) * 0x101,
+					B: uint16(premultiplied.B) * 0x101, A: uint16(premultiplied.A) * 0x101,
+				}},
+			} {
+				if expected, actual := c.Combine(pair[0]), c.Combine(pair[1]); expected != actual {
+					t.Errorf("%s gave %d for %#v, but %d for %#v", c.Name(), expected, pair[0], actual, pair[1])
+				}
+			}
+		}
+
+		for v := 0; v <= 0xff; v += 0x11 {
+			gray, gray16 := color.Gray{Y: uint8(v)}, color.Gray16{Y: uint16(v) * 0x101}
+			if expected, actual := c.Combine(gray), c.Combine(gray16); expected != actual {
+				t.Errorf("%s gave %d for %#v, but %d for %#v", c.Name(), expected, gray, actual, gray16)
+			}
+		}
+	})
+
+	t.Run("Range", func(t *testing.T) {
+		for _, clr := range samples() {
+			if key := c.Combine(clr); key < md.Min || key > md.Max {
+				t.Errorf("%s gave %d for %#v, which is outside of [%d, %d]", c.Name(), key, clr, md.Min, md.Max)
+			}
+		}
+	})
+
+	t.Run("IgnoresAlpha", func(t *testing.T) {
+		if !md.IgnoresAlpha {
+			t.Skip("the combiner uses alpha")
+		}
+
+		for _, clr := range samples8() {
+			opaque := color.NRGBA64{R: uint16(clr.R) * 0x101, G: uint16(clr.G) * 0x101, B: uint16(clr.B) * 0x101,
+				A: 0xffff}
+			expected := md.Fraction(c.Combine(opaque))
+
+			for _, a := range []uint16{0xc000, 0x8000} {
+				translucent := opaque
+				translucent.A = a
+
+				actual := md.Fraction(c.Combine(translucent))
+
+				diff := math.Abs(expected - actual)
+				if md.Cyclic {
+					diff = math.Min(diff, 1-diff)
+				}
+
+				if diff > opts.AlphaTolerance {
+					t.Errorf("%s gave %v of its range for %#v, but %v for %#v", c.Name(), expected, opaque,
+						actual, translucent)
+				}
+			}
+		}
+	})
+
+	imgCmb, ok := c.(combiner.ImageCombiner)
+	if !ok {
+		return
+	}
+
+	t.Run("Image", func(t *testing.T) {
+		for _, img := range images() {
+			bounds := img.Bounds()
+
+			var points []image.Point
+			var expected []uint64
+			for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+				for x := bounds.Min.X; x < bounds.Max.X; x++ {
+					key := imgCmb.CombineAt(img, x, y)
+					if again := imgCmb.CombineAt(img, x, y); again != key {
+						t.Errorf("%s gave %d, then %d, at %d,%d of a %T", c.Name(), key, again, x, y, img)
+					}
+
+					if key < md.Min || key > md.Max {
+						t.Errorf("%s gave %d at %d,%d of a %T, which is outside of [%d, %d]", c.Name(), key,
+							x, y, img, md.Min, md.Max)
+					}
+
+					points = append(points, image.Pt(x, y))
+					expected = append(expected, key)
+				}
+			}
+
+			for _, i := range concurrently(len(points), func(i int) uint64 {
+				return imgCmb.CombineAt(img, points[i].X, points[i].Y)
+			}, expected) {
+				t.Errorf("%s gave a different value at %v of a %T when used concurrently", c.Name(), points[i], img)
+			}
+		}
+	})
+}
+
+// goroutines is how many goroutines to use at once to check for concurrency problems
+const goroutines = 8
+
+// concurrently calls key for each i in [0, n) from several goroutines at once, and gives the
+// indexes where the results didn't match expected
+func concurrently(n int, key func(i int) uint64, expected []uint64) []int {
+	mismatched := map[int]bool{}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+
+		go func(g int) {
+			defer wg.Done()
+
+			// Each goroutine starts somewhere different, so they aren't all in lockstep
+			for j := 0; j < n; j++ {
+				i := (j + g*n/goroutines) % n
+				if key(i) != expected[i] {
+					mu.Lock()
+					mismatched[i] = true
+					mu.Unlock()
+				}
+			}
+		}(g)
+	}
+
+	wg.Wait()
+
+	var indexes []int
+	for i := 0; i < n; i++ {
+		if mismatched[i] {
+			indexes = append(indexes, i)
+		}
+	}
+
+	return indexes
+}
+
+// samples8 gives a spread of 8-bit colors, including translucent and fully transparent ones
+func samples8() []color.NRGBA {
+	levels := []uint8{0, 0x33, 0x80, 0xcc, 0xff}
+
+	var colors []color.NRGBA
+	for _, a := range []uint8{0xff, 0x80, 0x01, 0} {
+		for _, r := range levels {
+			for _, g := range levels {
+				for _, b := range levels {
+					colors = append(colors, color.NRGBA{R: r, G: g, B: b, A: a})
+				}
+			}
+		}
+	}
+
+	return colors
+}
+
+// samples gives the colors of samples8, and some random 16-bit colors, which are always the same
+func samples() []color.Color {
+	var colors []color.Color
+	for _, c := range samples8() {
+		colors = append(colors, c)
+	}
+
+	random := rand.New(rand.NewSource(1))
+	for i := 0; i < 256; i++ {
+		colors = append(colors, color.NRGBA64{
+			R: uint16(random.Intn(0x10000)),
+			G: uint16(random.Intn(0x10000)),
+			B: uint16(random.Intn(0x10000)),
+			A: uint16(random.Intn(0x10000)),
+		})
+	}
+
+	return colors
+}
+
+// images gives some small images for checking combiner.ImageCombiners, with bounds that don't start
+// at 0,0
+func images() []image.Image {
+	bounds := image.Rect(-3, 2, 13, 14)
+	colors := samples()
+
+	nrgba := image.NewNRGBA(bounds)
+	paletted := image.NewPaletted(bounds, color.Palette{
+		color.Black, color.White, color.NRGBA{R: 0xff, A: 0xff}, color.NRGBA{B: 0xff, A: 0x80},
+	})
+
+	i := 0
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			nrgba.Set(x, y, colors[i%len(colors)])
+			paletted.SetColorIndex(x, y, uint8(i%len(paletted.Palette)))
+			i++
+		}
+	}
+
+	return []image.Image{nrgba, paletted}
+}
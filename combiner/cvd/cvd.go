@@ -0,0 +1,137 @@
+// Package cvd implements combiner.Combiner using the luminance of each color as seen with a color
+// vision deficiency (CVD). Sorting with them shows how an ordering collapses for people with
+// protanopia, deuteranopia or tritanopia, which makes sorted images useful as accessibility test
+// charts.
+//
+// The deficiencies are simulated with the matrices from Machado, Oliveira and Fernandes' "A
+// Physiologically-based Model for Simulation of Color Vision Deficiency"
+// (https://doi.org/10.1109/TVCG.2009.113), applied in linear light. Partial deficiencies are
+// approximated by interpolating between normal vision and the full deficiency.
+package cvd
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+
+	"github.com/dcormier/go-pixelsort/colorspace"
+	"github.com/dcormier/go-pixelsort/combiner"
+)
+
+// Deficiency is a type of color vision deficiency
+type Deficiency int
+
+const (
+	// Protanopia is the absence of long-wavelength (red) cones
+	Protanopia Deficiency = iota
+
+	// Deuteranopia is the absence of medium-wavelength (green) cones
+	Deuteranopia
+
+	// Tritanopia is the absence of short-wavelength (blue) cones
+	Tritanopia
+)
+
+func (d Deficiency) String() string {
+	switch d {
+	case Protanopia:
+		return "protanopia"
+	case Deuteranopia:
+		return "deuteranopia"
+	case Tritanopia:
+		return "tritanopia"
+	}
+
+	return fmt.Sprintf("Deficiency(%d)", int(d))
+}
+
+// matrices simulate each Deficiency at full severity, on linear RGB
+var matrices = map[Deficiency][3][3]float64{
+	Protanopia: {
+		{0.152286, 1.052583, -0.204868},
+		{0.114503, 0.786281, 0.099216},
+		{-0.003882, -0.048116, 1.051998},
+	},
+	Deuteranopia: {
+		{0.367322, 0.860646, -0.227968},
+		{0.280085, 0.672501, 0.047413},
+		{-0.011820, 0.042940, 0.968881},
+	},
+	Tritanopia: {
+		{1.255528, -0.076749, -0.178779},
+		{-0.078411, 0.930809, 0.147602},
+		{0.004733, 0.691367, 0.303900},
+	},
+}
+
+var (
+	// ProtanopiaCombiner keys on luminance as seen with protanopia
+	ProtanopiaCombiner = New(Protanopia, 1)
+
+	// DeuteranopiaCombiner keys on luminance as seen with deuteranopia
+	DeuteranopiaCombiner = New(Deuteranopia, 1)
+
+	// TritanopiaCombiner keys on luminance as seen with tritanopia
+	TritanopiaCombiner = New(Tritanopia, 1)
+)
+
+func init() {
+	for _, d := range []Deficiency{Protanopia, Deuteranopia, Tritanopia} {
+		d := d
+
+		combiner.Register(d.String(), func(p *combiner.Params) (combiner.Combiner, error) {
+			return New(d, p.FloatIn("severity", 1, 0, 1)), nil
+		})
+	}
+}
+
+// Simulate gives c as it would be seen with the given deficiency, at the given severity (in
+// [0, 1]). The result is clamped to the sRGB gamut.
+func Simulate(c color.Color, d Deficiency, severity float64) colorspace.LinearRGB {
+	lin := colorspace.LinearRGBOf(c)
+	in := [3]float64{lin.R, lin.G, lin.B}
+	m := matrices[d]
+
+	var out [3]float64
+	for i := range out {
+		simulated := m[i][0]*in[0] + m[i][1]*in[1] + m[i][2]*in[2]
+		out[i] = math.Max(0, math.Min(1, in[i]+(simulated-in[i])*severity))
+	}
+
+	return colorspace.LinearRGB{R: out[0], G: out[1], B: out[2]}
+}
+
+var _ combiner.Combiner = (*cvd)(nil)
+
+type cvd struct {
+	deficiency Deficiency
+	severity   float64
+}
+
+// New creates a combiner.Combiner that uses luminance as seen with the given deficiency, at the
+// given severity (in [0, 1])
+func New(d Deficiency, severity float64) combiner.Combiner {
+	return &cvd{deficiency: d, severity: severity}
+}
+
+func (c *cvd) Name() string {
+	if c.severity == 1 {
+		return c.deficiency.String() + " luminance"
+	}
+
+	return fmt.Sprintf("%v luminance (severity %v)", c.deficiency, c.severity)
+}
+
+func (c *cvd) Metadata() combiner.Metadata {
+	return combiner.Metadata{
+		Description:  fmt.Sprintf("Relative luminance as seen with %v (severity %v)", c.deficiency, c.severity),
+		Max:          0xffff,
+		IgnoresAlpha: true,
+	}
+}
+
+func (c *cvd) Combine(clr color.Color) uint64 {
+	y := Simulate(clr, c.deficiency, c.severity).XYZ().Y
+
+	return uint64(math.Min(1, y)*0xffff + 0.5)
+}
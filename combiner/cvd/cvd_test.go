@@ -0,0 +1,74 @@
+package cvd
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dcormier/go-pixelsort/colorspace"
+	"github.com/dcormier/go-pixelsort/combiner"
+	"github.com/dcormier/go-pixelsort/combiner/combinertest"
+)
+
+func TestSimulate(t *testing.T) {
+	t.Parallel()
+
+	for _, d := range []Deficiency{Protanopia, Deuteranopia, Tritanopia} {
+		// Grays look the same to everyone
+		for _, v := range []uint8{0, 0x40, 0x80, 0xff} {
+			gray := color.Gray{Y: v}
+			expected := colorspace.LinearRGBOf(gray)
+			actual := Simulate(gray, d, 1)
+
+			assert.InDelta(t, expected.R, actual.R, 1e-3, "%v %v", d, gray)
+			assert.InDelta(t, expected.G, actual.G, 1e-3, "%v %v", d, gray)
+			assert.InDelta(t, expected.B, actual.B, 1e-3, "%v %v", d, gray)
+		}
+
+		// A severity of 0 is normal vision
+		c := color.NRGBA{R: 0xc0, G: 0x30, B: 0x80, A: 0xff}
+		assert.Equal(t, colorspace.LinearRGBOf(c), Simulate(c, d, 0), d.String())
+	}
+}
+
+func TestCollapse(t *testing.T) {
+	t.Parallel()
+
+	red := color.NRGBA{R: 0xff, A: 0xff}
+	green := color.NRGBA{G: 0x80, A: 0xff}
+
+	// Red is much dimmer without red cones
+	assert.True(t, ProtanopiaCombiner.Combine(red) < DeuteranopiaCombiner.Combine(red))
+
+	// Red and green are hard to tell apart with protanopia, so their luminance ends up close
+	diff := func(a, b uint64) float64 {
+		if a > b {
+			return float64(a - b)
+		}
+
+		return float64(b - a)
+	}
+
+	assert.True(t, diff(ProtanopiaCombiner.Combine(red), ProtanopiaCombiner.Combine(green)) <
+		diff(New(Protanopia, 0).Combine(red), New(Protanopia, 0).Combine(green)))
+
+	assert.Equal(t, "tritanopia luminance", TritanopiaCombiner.Name())
+	assert.Equal(t, "deuteranopia luminance (severity 0.5)", New(Deuteranopia, 0.5).Name())
+}
+
+func TestConformance(t *testing.T) {
+	t.Parallel()
+
+	for _, cmb := range []combiner.Combiner{
+		ProtanopiaCombiner,
+		DeuteranopiaCombiner,
+		TritanopiaCombiner,
+		New(Deuteranopia, 0.5),
+	} {
+		cmb := cmb
+		t.Run(cmb.Name(), func(t *testing.T) {
+			combinertest.Run(t, cmb)
+		})
+	}
+}
@@ -0,0 +1,233 @@
+// Package decorate wraps a combiner.Combiner to give a new one, with keys that are inverted,
+// quantized, normalized or blended with another combiner's.
+//
+// The decorators rely on the combiner.Metadata of the combiners they wrap to know their range of
+// values, so wrapping a combiner that isn't a combiner.Describer works, but may not be useful.
+// Wrapping a combiner.ImageCombiner gives a combiner.ImageCombiner.
+//
+// They're also registered, so they can be used in specs (see combiner.Parse):
+//
+//	invert?of=perceivedoption2
+//	quantize?of=(hue?origin=200)&levels=8
+//	normalize?of=red&max=255
+//	weighted?a=red&b=blue&w=0.25
+package decorate
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/dcormier/go-pixelsort/combiner"
+)
+
+// NormalizedMax is the largest value given by Weighted
+const NormalizedMax = math.MaxUint32
+
+func init() {
+	combiner.Register("invert", func(p *combiner.Params) (combiner.Combiner, error) {
+		inner := p.Combiner("of", nil)
+		if p.Err() != nil {
+			return nil, p.Err()
+		}
+
+		return Invert(inner), nil
+	})
+
+	combiner.Register("quantize", func(p *combiner.Params) (combiner.Combiner, error) {
+		inner := p.Combiner("of", nil)
+		levels := p.IntIn("levels", 8, 1, math.MaxInt32)
+		if p.Err() != nil {
+			return nil, p.Err()
+		}
+
+		return Quantize(inner, levels), nil
+	})
+
+	combiner.Register("normalize", func(p *combiner.Params) (combiner.Combiner, error) {
+		inner := p.Combiner("of", nil)
+		max := p.Float("max", 0xffff)
+		if max < 1 || max > math.MaxUint32 {
+			p.Errorf("max", "%v is out of range [1, %d]", max, uint64(math.MaxUint32))
+		}
+
+		if p.Err() != nil {
+			return nil, p.Err()
+		}
+
+		return Normalize(inner, uint64(max)), nil
+	})
+
+	combiner.Register("weighted", func(p *combiner.Params) (combiner.Combiner, error) {
+		a := p.Combiner("a", nil)
+		b := p.Combiner("b", nil)
+		w := p.FloatIn("w", 0.5, 0, 1)
+		if p.Err() != nil {
+			return nil, p.Err()
+		}
+
+		return Weighted(a, b, w), nil
+	})
+}
+
+var (
+	_ combiner.Combiner      = (*decorator)(nil)
+	_ combiner.ImageCombiner = (*imageDecorator)(nil)
+)
+
+// decorate creates a decorator, which is also a combiner.ImageCombiner if any of the combiners it
+// wraps are
+func decorate(d *decorator) combiner.Combiner {
+	for _, inner := range d.inner {
+		if _, ok := inner.(combiner.ImageCombiner); ok {
+			return &imageDecorator{d}
+		}
+	}
+
+	return d
+}
+
+// decorator is a combiner whose values are a function of the values of other combiners
+type decorator struct {
+	name     string
+	metadata combiner.Metadata
+	inner    []combiner.Combiner
+
+	// transform turns the values from each of the inner combiners (in order) into the new value
+	transform func(keys []uint64) uint64
+}
+
+func (d *decorator) Name() string {
+	return d.name
+}
+
+func (d *decorator) Metadata() combiner.Metadata {
+	return d.metadata
+}
+
+func (d *decorator) Combine(c color.Color) uint64 {
+	keys := make([]uint64, len(d.inner))
+	for i, inner := range d.inner {
+		keys[i] = inner.Combine(c)
+	}
+
+	return d.transform(keys)
+}
+
+// imageDecorator is a decorator that wraps at least one combiner.ImageCombiner
+type imageDecorator struct {
+	*decorator
+}
+
+func (d *imageDecorator) CombineAt(img image.Image, x, y int) uint64 {
+	keys := make([]uint64, len(d.inner))
+	for i, inner := range d.inner {
+		if imgCmb, ok := inner.(combiner.ImageCombiner); ok {
+			keys[i] = imgCmb.CombineAt(img, x, y)
+		} else {
+			keys[i] = inner.Combine(img.At(x, y))
+		}
+	}
+
+	return d.transform(keys)
+}
+
+// Invert gives a combiner.Combiner that reverses the order of c's values within its range, so
+// that sorting in ascending order with it is like sorting in descending order with c.
+func Invert(c combiner.Combiner) combiner.Combiner {
+	md := combiner.MetadataOf(c)
+
+	return decorate(&decorator{
+		name: "inverted " + c.Name(),
+		metadata: combiner.Metadata{
+			Description:  "Inverted: " + md.Description,
+			Min:          md.Min,
+			Max:          md.Max,
+			IgnoresAlpha: md.IgnoresAlpha,
+			Cyclic:       md.Cyclic,
+		},
+		inner: []combiner.Combiner{c},
+		transform: func(keys []uint64) uint64 {
+			key := keys[0]
+
+			switch {
+			case key <= md.Min:
+				return md.Max
+			case key >= md.Max:
+				return md.Min
+			}
+
+			return md.Max - (key - md.Min)
+		},
+	})
+}
+
+// Quantize gives a combiner.Combiner that splits c's range into the given number of equally sized
+// levels, and gives the level (from 0 to levels-1) that each of c's values falls in. Colors that
+// c puts in order stay in order, but colors in the same level become equal, giving posterized
+// bands when sorted with a stable sort.
+func Quantize(c combiner.Combiner, levels int) combiner.Combiner {
+	md := combiner.MetadataOf(c)
+
+	return decorate(&decorator{
+		name: fmt.Sprintf("%s (quantized to %d levels)", c.Name(), levels),
+		metadata: combiner.Metadata{
+			Description:  fmt.Sprintf("Quantized to %d levels: %s", levels, md.Description),
+			Max:          uint64(levels - 1),
+			IgnoresAlpha: md.IgnoresAlpha,
+			Cyclic:       md.Cyclic,
+		},
+		inner: []combiner.Combiner{c},
+		transform: func(keys []uint64) uint64 {
+			level := uint64(md.Fraction(keys[0]) * float64(levels))
+			if level >= uint64(levels) {
+				level = uint64(levels - 1)
+			}
+
+			return level
+		},
+	})
+}
+
+// Normalize gives a combiner.Combiner that maps c's range onto [0, max]. Colors that c puts in
+// order stay in order, but may become equal if c's range is larger.
+func Normalize(c combiner.Combiner, max uint64) combiner.Combiner {
+	md := combiner.MetadataOf(c)
+
+	return decorate(&decorator{
+		name: fmt.Sprintf("%s (normalized to %d)", c.Name(), max),
+		metadata: combiner.Metadata{
+			Description:  md.Description,
+			Max:          max,
+			IgnoresAlpha: md.IgnoresAlpha,
+			Cyclic:       md.Cyclic,
+		},
+		inner: []combiner.Combiner{c},
+		transform: func(keys []uint64) uint64 {
+			return uint64(md.Fraction(keys[0])*float64(max) + 0.5)
+		},
+	})
+}
+
+// Weighted gives a combiner.Combiner that mixes the values of a and b, after normalizing both of
+// them to [0, NormalizedMax]. w (in [0, 1]) is the weight given to b; a gets 1-w.
+func Weighted(a, b combiner.Combiner, w float64) combiner.Combiner {
+	mdA := combiner.MetadataOf(a)
+	mdB := combiner.MetadataOf(b)
+
+	return decorate(&decorator{
+		name: fmt.Sprintf("weighted (%v %s + %v %s)", 1-w, a.Name(), w, b.Name()),
+		metadata: combiner.Metadata{
+			Description:  fmt.Sprintf("%v of %s, plus %v of %s", 1-w, a.Name(), w, b.Name()),
+			Max:          NormalizedMax,
+			IgnoresAlpha: mdA.IgnoresAlpha && mdB.IgnoresAlpha,
+		},
+		inner: []combiner.Combiner{a, b},
+		transform: func(keys []uint64) uint64 {
+			mixed := (1-w)*mdA.Fraction(keys[0]) + w*mdB.Fraction(keys[1])
+
+			return uint64(mixed*NormalizedMax + 0.5)
+		},
+	})
+}
@@ -0,0 +1,200 @@
+package decorate
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dcormier/go-pixelsort/combiner"
+	"github.com/dcormier/go-pixelsort/combiner/channel"
+	"github.com/dcormier/go-pixelsort/combiner/combinertest"
+	"github.com/dcormier/go-pixelsort/combiner/hue"
+	"github.com/dcormier/go-pixelsort/combiner/perceivedoption2"
+	"github.com/dcormier/go-pixelsort/combiner/radial"
+)
+
+// colors is a spread of colors to check orderings with
+func colors() []color.Color {
+	var colors []color.Color
+
+	for r := 0; r <= 0xff; r += 0x33 {
+		for g := 0; g <= 0xff; g += 0x33 {
+			for b := 0; b <= 0xff; b += 0x33 {
+				colors = append(colors, color.NRGBA{R: uint8(r), G: uint8(g), B: uint8(b), A: 0xff})
+			}
+		}
+	}
+
+	return colors
+}
+
+// relation describes how a decorated combiner is expected to order a pair of colors, given how
+// the wrapped combiner orders them (-1, 0 or 1)
+type relation func(inner int) (allowed []int)
+
+var (
+	// same means the decorated combiner must order every pair the same way
+	same relation = func(inner int) []int { return []int{inner} }
+
+	// reversed means the decorated combiner must order every pair the opposite way
+	reversed relation = func(inner int) []int { return []int{-inner} }
+
+	// merged means pairs can become equal, but never swap
+	merged relation = func(inner int) []int { return []int{inner, 0} }
+)
+
+func compare(a, b uint64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+
+	return 0
+}
+
+func TestOrdering(t *testing.T) {
+	t.Parallel()
+
+	lum := perceivedoption2.Combiner
+
+	for _, test := range []struct {
+		name      string
+		inner     combiner.Combiner
+		decorated combiner.Combiner
+		relation  relation
+	}{
+		{"invert red", channel.Red, Invert(channel.Red), reversed},
+		{"invert hue", hue.Combiner, Invert(hue.Combiner), reversed},
+		{"invert twice", lum, Invert(Invert(lum)), same},
+		{"quantize red", channel.Red, Quantize(channel.Red, 4), merged},
+		{"quantize luminance", lum, Quantize(lum, 16), merged},
+		{"quantize to 1 level", lum, Quantize(lum, 1), func(int) []int { return []int{0} }},
+		{"normalize red up", channel.Red, Normalize(channel.Red, NormalizedMax), same},
+		{"normalize red to same", channel.Red, Normalize(channel.Red, 0xffff), same},
+		{"normalize red down", channel.Red, Normalize(channel.Red, 0xff), same},
+		{"normalize luminance down", lum, Normalize(lum, 10), merged},
+		{"weighted all a", channel.Red, Weighted(channel.Red, channel.Blue, 0), same},
+		{"weighted all b", channel.Blue, Weighted(channel.Red, channel.Blue, 1), same},
+		{"weighted self", lum, Weighted(lum, lum, 0.3), same},
+		{"weighted inverted", lum, Weighted(lum, Invert(lum), 1), reversed},
+	} {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			colors := colors()
+
+			for i, c1 := range colors {
+				for _, c2 := range colors[i+1:] {
+					inner := compare(test.inner.Combine(c1), test.inner.Combine(c2))
+					decorated := compare(test.decorated.Combine(c1), test.decorated.Combine(c2))
+
+					require.Contains(t, test.relation(inner), decorated, "%v vs %v", c1, c2)
+				}
+			}
+		})
+	}
+}
+
+func TestRanges(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range []struct {
+		decorated combiner.Combiner
+		min, max  uint64
+	}{
+		{Invert(channel.Red), 0, 0xffff},
+		{Quantize(channel.Red, 4), 0, 3},
+		{Normalize(channel.Red, 255), 0, 255},
+		{Weighted(channel.Red, hue.Combiner, 0.5), 0, NormalizedMax},
+	} {
+		md := combiner.MetadataOf(test.decorated)
+		assert.Equal(t, test.min, md.Min, test.decorated.Name())
+		assert.Equal(t, test.max, md.Max, test.decorated.Name())
+
+		seen := map[uint64]bool{}
+		for _, c := range colors() {
+			key := test.decorated.Combine(c)
+			assert.True(t, test.min <= key && key <= test.max, "%s: %v out of range", test.decorated.Name(), key)
+			seen[key] = true
+		}
+
+		if test.max < 10 {
+			assert.Len(t, seen, int(test.max+1), test.decorated.Name())
+		}
+	}
+
+	assert.True(t, combiner.MetadataOf(Invert(hue.Combiner)).Cyclic)
+	assert.True(t, combiner.MetadataOf(Quantize(channel.Red, 4)).IgnoresAlpha)
+	assert.False(t, combiner.MetadataOf(Weighted(channel.Red, channel.Alpha, 0.5)).IgnoresAlpha)
+}
+
+func TestImageCombiner(t *testing.T) {
+	t.Parallel()
+
+	_, ok := Invert(radial.Combiner).(combiner.ImageCombiner)
+	assert.True(t, ok)
+
+	_, ok = Weighted(channel.Red, radial.Combiner, 0.5).(combiner.ImageCombiner)
+	assert.True(t, ok)
+
+	_, ok = Invert(channel.Red).(combiner.ImageCombiner)
+	assert.False(t, ok)
+}
+
+func TestSpecs(t *testing.T) {
+	t.Parallel()
+
+	for spec, expected := range map[string]string{
+		"invert?of=red":                                "inverted red",
+		"quantize?of=(hue?origin=200)&levels=4":        "hue (from 200°) (quantized to 4 levels)",
+		"normalize?of=red&max=255":                     "red (normalized to 255)",
+		"weighted?a=red&b=blue&w=0.25":                 "weighted (0.75 red + 0.25 blue)",
+		"invert?of=(quantize?of=red&levels=2)":         "inverted red (quantized to 2 levels)",
+		"weighted?a=(invert?of=red)&b=(hue?origin=10)": "weighted (0.5 inverted red + 0.5 hue (from 10°))",
+	} {
+		cmb, err := combiner.Parse(spec)
+		require.NoError(t, err, spec)
+		assert.Equal(t, expected, cmb.Name(), spec)
+	}
+
+	for spec, expected := range map[string]string{
+		"invert":                     `parameter "of": required, but not given`,
+		"quantize?of=red&levels=0":   `parameter "levels": 0 is out of range`,
+		"normalize?of=red&max=0":     `parameter "max": 0 is out of range`,
+		"weighted?a=red&b=nope":      `parameter "b": combiner spec "nope": unknown combiner "nope"`,
+		"weighted?a=red&b=blue&w=2":  `parameter "w": 2 is out of range [0, 1]`,
+		"invert?of=(hue?origin=abc)": `parameter "of": combiner spec "hue?origin=abc": parameter "origin"`,
+	} {
+		_, err := combiner.Parse(spec)
+		require.Error(t, err, spec)
+		assert.Contains(t, err.Error(), expected, spec)
+	}
+}
+
+func TestConformance(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range []struct {
+		cmb  combiner.Combiner
+		opts combinertest.Options
+	}{
+		{cmb: Invert(channel.Red)},
+		{cmb: Invert(radial.Combiner)},
+		// Colors right at the edge of a level can be pushed into the next one by the rounding that
+		// comes with alpha-premultiplication
+		{cmb: Quantize(hue.Combiner, 8), opts: combinertest.Options{AlphaTolerance: 1.0 / 7}},
+		{cmb: Normalize(channel.Red, 0xff)},
+		{cmb: Weighted(channel.Red, hue.Combiner, 0.25)},
+	} {
+		test := test
+		t.Run(test.cmb.Name(), func(t *testing.T) {
+			combinertest.RunWithOptions(t, test.cmb, test.opts)
+		})
+	}
+}
@@ -0,0 +1,142 @@
+// Package distance implements combiner.Combiner using the distance of each color from a reference
+// color, so sorting in ascending order puts the colors closest to the reference first.
+// Colors are compared without alpha-premultiplication.
+package distance
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"strings"
+
+	"github.com/dcormier/go-pixelsort/colorspace"
+	"github.com/dcormier/go-pixelsort/combiner"
+)
+
+// Metric is a way of measuring the difference between two colors
+type Metric int
+
+const (
+	// RGB is the Euclidean distance between two colors in sRGB
+	RGB Metric = iota
+
+	// CIE76 is the Euclidean distance between two colors in CIE L*a*b*
+	CIE76
+
+	// CIEDE2000 is the CIEDE2000 color difference
+	CIEDE2000
+)
+
+// Metrics are all of the known Metrics
+var Metrics = []Metric{RGB, CIE76, CIEDE2000}
+
+func (m Metric) String() string {
+	switch m {
+	case RGB:
+		return "rgb"
+	case CIE76:
+		return "cie76"
+	case CIEDE2000:
+		return "ciede2000"
+	}
+
+	return fmt.Sprintf("Metric(%d)", int(m))
+}
+
+// ParseMetric returns the Metric with the given name (as returned by Metric.String)
+func ParseMetric(name string) (Metric, error) {
+	names := make([]string, len(Metrics))
+	for i, m := range Metrics {
+		if strings.EqualFold(name, m.String()) {
+			return m, nil
+		}
+
+		names[i] = m.String()
+	}
+
+	return 0, fmt.Errorf("unknown distance metric %q (expected one of %s)", name, strings.Join(names, ", "))
+}
+
+func init() {
+	combiner.Register("distance", func(p *combiner.Params) (combiner.Combiner, error) {
+		ref := p.Color("ref", nil)
+
+		metric, err := ParseMetric(p.String("metric", CIEDE2000.String()))
+		if err != nil {
+			p.Errorf("metric", "%v", err)
+		}
+
+		if p.Err() != nil {
+			return nil, p.Err()
+		}
+
+		return New(ref, metric), nil
+	})
+}
+
+// maxDistances are the furthest apart that any two colors in the sRGB gamut can be, for each
+// Metric (rounded up)
+var maxDistances = map[Metric]float64{
+	RGB:       math.Sqrt(3),
+	CIE76:     260,
+	CIEDE2000: 125,
+}
+
+// keyScale is what distances are multiplied by to turn them into keys. RGB distances are measured
+// with channels in [0, 1], so this keeps plenty of precision for them, and CIE color differences
+// (which are in the hundreds at most) still fit easily.
+const keyScale = 1 << 20
+
+var _ combiner.Combiner = (*distance)(nil)
+
+type distance struct {
+	reference color.Color
+	metric    Metric
+
+	refR, refG, refB float64
+	refLab           colorspace.Lab
+}
+
+// New creates a combiner.Combiner that measures the distance of each color from reference using
+// metric
+func New(reference color.Color, metric Metric) combiner.Combiner {
+	d := &distance{
+		reference: reference,
+		metric:    metric,
+		refLab:    colorspace.LabOf(reference),
+	}
+
+	d.refR, d.refG, d.refB, _ = colorspace.NRGBA(reference)
+
+	return d
+}
+
+func (d *distance) Name() string {
+	return fmt.Sprintf("distance (%v from %s)", d.metric, colorspace.FormatHex(d.reference))
+}
+
+func (d *distance) Metadata() combiner.Metadata {
+	return combiner.Metadata{
+		Description:  fmt.Sprintf("Distance from %s, using %v", colorspace.FormatHex(d.reference), d.metric),
+		Max:          uint64(maxDistances[d.metric]*keyScale + 0.5),
+		IgnoresAlpha: true,
+	}
+}
+
+func (d *distance) Combine(c color.Color) uint64 {
+	var dist float64
+
+	switch d.metric {
+	case CIE76:
+		dist = colorspace.DeltaE76(d.refLab, colorspace.LabOf(c))
+
+	case CIEDE2000:
+		dist = colorspace.DeltaE2000(d.refLab, colorspace.LabOf(c))
+
+	default:
+		r, g, b, _ := colorspace.NRGBA(c)
+		dist = math.Sqrt((r-d.refR)*(r-d.refR) + (g-d.refG)*(g-d.refG) + (b-d.refB)*(b-d.refB))
+	}
+
+	return uint64(dist*keyScale + 0.5)
+}
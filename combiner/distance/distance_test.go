@@ -0,0 +1,58 @@
+package distance
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dcormier/go-pixelsort/combiner"
+	"github.com/dcormier/go-pixelsort/combiner/combinertest"
+)
+
+func TestParseMetric(t *testing.T) {
+	t.Parallel()
+
+	for _, m := range Metrics {
+		parsed, err := ParseMetric(m.String())
+		require.NoError(t, err)
+		assert.Equal(t, m, parsed)
+	}
+
+	_, err := ParseMetric("manhattan")
+	assert.EqualError(t, err, `unknown distance metric "manhattan" (expected one of rgb, cie76, ciede2000)`)
+}
+
+func TestCombine(t *testing.T) {
+	t.Parallel()
+
+	brandBlue := color.NRGBA{R: 0x00, G: 0x50, B: 0xff, A: 0xff}
+	nearBlue := color.NRGBA{R: 0x10, G: 0x60, B: 0xf0, A: 0xff}
+	purple := color.NRGBA{R: 0x80, G: 0x00, B: 0xff, A: 0xff}
+	orange := color.NRGBA{R: 0xff, G: 0x80, B: 0x00, A: 0xff}
+
+	for _, m := range Metrics {
+		cmb := New(brandBlue, m)
+
+		assert.Equal(t, "distance ("+m.String()+" from #0050ff)", cmb.Name())
+		assert.Zero(t, cmb.Combine(brandBlue), m.String())
+		assert.True(t, cmb.Combine(nearBlue) < cmb.Combine(purple), m.String())
+		assert.True(t, cmb.Combine(purple) < cmb.Combine(orange), m.String())
+	}
+}
+
+func TestConformance(t *testing.T) {
+	t.Parallel()
+
+	for _, cmb := range []combiner.Combiner{
+		New(color.NRGBA{R: 0x33, G: 0x66, B: 0x99, A: 0xff}, RGB),
+		New(color.NRGBA{R: 0x33, G: 0x66, B: 0x99, A: 0xff}, CIE76),
+		New(color.NRGBA{R: 0x33, G: 0x66, B: 0x99, A: 0xff}, CIEDE2000),
+	} {
+		cmb := cmb
+		t.Run(cmb.Name(), func(t *testing.T) {
+			combinertest.Run(t, cmb)
+		})
+	}
+}
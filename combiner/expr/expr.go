@@ -0,0 +1,150 @@
+// Package expr implements combiner.Combiner using a small arithmetic expression, such as
+// "0.3*r + 0.59*g + 0.11*b" or "max(r, g, b) - min(r, g, b)". Expressions are parsed and compiled
+// once, by Compile.
+//
+// Expressions can use numbers, parentheses, the operators + - * / % and ^ (exponentiation), and
+// these variables:
+//
+//	r, g, b, a  the red, green, blue and alpha channels, in [0, 1] and not alpha-premultiplied
+//	h           the hue, in degrees, in [0, 360)
+//	s, v        the HSV saturation and value, in [0, 1]
+//	l           the HSL lightness, in [0, 1]
+//	pi          π
+//
+// along with the functions sqrt, pow, abs, min, max, floor, ceil, exp, log, sin and cos. min and
+// max take any number of arguments.
+//
+// Larger results sort after smaller ones; negative results are fine.
+package expr
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+
+	"github.com/dcormier/go-pixelsort/colorspace"
+	"github.com/dcormier/go-pixelsort/combiner"
+)
+
+func init() {
+	combiner.Register("expr", func(p *combiner.Params) (combiner.Combiner, error) {
+		src := p.String("e", "")
+		if !p.Has("e") {
+			p.Errorf("e", "required, but not given")
+			return nil, nil
+		}
+
+		cmb, err := Compile(src)
+		if err != nil {
+			p.Errorf("e", "%v", err)
+		}
+
+		return cmb, nil
+	})
+}
+
+// SyntaxError describes a problem parsing an expression
+type SyntaxError struct {
+	// Col is the 1-based column of the expression that the problem was found at
+	Col int
+
+	Msg string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("column %d: %s", e.Col, e.Msg)
+}
+
+// need is a set of the conversions that an expression needs done before it can be evaluated
+type need uint8
+
+const (
+	needRGBA need = 1 << iota
+	needHSV
+	needHSL
+
+	// needAlpha means the expression uses alpha. It's tracked separately, for Metadata.
+	needAlpha
+)
+
+// vars holds the values of the variables for the color being combined
+type vars struct {
+	r, g, b, a float64
+	h, s, v, l float64
+}
+
+var _ combiner.Combiner = (*expression)(nil)
+
+type expression struct {
+	src  string
+	root node
+	need need
+}
+
+// Compile parses src into a combiner.Combiner. If src isn't a valid expression, the error will
+// be a *SyntaxError.
+func Compile(src string) (combiner.Combiner, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+
+	root, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, p.errorf(t, "expected an operator or end of expression, found %s", t)
+	}
+
+	return &expression{src: src, root: root, need: p.need}, nil
+}
+
+// MustCompile is like Compile, but panics if src can't be compiled
+func MustCompile(src string) combiner.Combiner {
+	cmb, err := Compile(src)
+	if err != nil {
+		panic(fmt.Sprintf("expr: compiling %q: %v", src, err))
+	}
+
+	return cmb
+}
+
+func (e *expression) Name() string {
+	return "expression (" + e.src + ")"
+}
+
+func (e *expression) Metadata() combiner.Metadata {
+	return combiner.Metadata{
+		Description:  "The result of " + e.src,
+		Max:          math.MaxUint64,
+		IgnoresAlpha: e.need&needAlpha == 0,
+	}
+}
+
+// eval evaluates the expression for c
+func (e *expression) eval(c color.Color) float64 {
+	var v vars
+
+	if e.need&(needRGBA|needAlpha) != 0 {
+		v.r, v.g, v.b, v.a = colorspace.NRGBA(c)
+	}
+
+	if e.need&needHSV != 0 {
+		hsv := colorspace.HSVOf(c)
+		v.h, v.s, v.v = hsv.H, hsv.S, hsv.V
+	}
+
+	if e.need&needHSL != 0 {
+		v.l = colorspace.HSLOf(c).L
+	}
+
+	return e.root(&v)
+}
+
+func (e *expression) Combine(c color.Color) uint64 {
+	return combiner.FloatKey(e.eval(c))
+}
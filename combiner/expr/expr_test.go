@@ -0,0 +1,115 @@
+package expr
+
+import (
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dcormier/go-pixelsort/combiner"
+	"github.com/dcormier/go-pixelsort/combiner/combinertest"
+)
+
+func TestEval(t *testing.T) {
+	t.Parallel()
+
+	// A half-transparent orange
+	c := color.NRGBA{R: 0xff, G: 0x80, B: 0x00, A: 0x80}
+	g := float64(0x80) / 0xff
+
+	for src, expected := range map[string]float64{
+		"1":                       1,
+		"1.5e2":                   150,
+		".5":                      0.5,
+		"1 + 2 * 3":               7,
+		"(1 + 2) * 3":             9,
+		"-2^2":                    -4,
+		"2^3^2":                   512,
+		"7 % 4":                   3,
+		"10 / 4 - -1":             3.5,
+		"r":                       1,
+		"g":                       g,
+		"b":                       0,
+		"a":                       g,
+		"0.3*r + 0.59*g + 0.11*b": 0.3 + 0.59*g,
+		"max(r,g,b)-min(r,g,b)":   1,
+		"MAX(r, g)":               1,
+		"min(g)":                  g,
+		"sqrt(pow(3, 2) + 4^2)":   5,
+		"abs(b - r)":              1,
+		"floor(h)":                30,
+		"s":                       1,
+		"v":                       1,
+		"l":                       (1 + 0) / 2.0,
+		"cos(pi)":                 -1,
+	} {
+		cmb, err := Compile(src)
+		require.NoError(t, err, src)
+
+		assert.InDelta(t, expected, cmb.(*expression).eval(c), 1e-3, src)
+	}
+}
+
+func TestSyntaxErrors(t *testing.T) {
+	t.Parallel()
+
+	for src, expected := range map[string]string{
+		"":                   `column 1: expected a number, variable, function or "(", found end of expression`,
+		"r +":                `column 4: expected a number, variable, function or "(", found end of expression`,
+		"r + x":              `column 5: unknown variable "x" (expected one of a, b, g, h, l, pi, r, s, v)`,
+		"0.3*r $ g":          `column 7: unexpected character '$'`,
+		"(r + g":             `column 7: expected ")" to close the "(" at column 1, found end of expression`,
+		"max(r, g":           `column 9: expected "," or ")" to close the "(" at column 4, found end of expression`,
+		"pow(r)":             `column 1: "pow" takes 2 argument(s), but was given 1`,
+		"hypot(r, g)":        `column 1: unknown function "hypot" (expected one of abs, ceil, cos, exp, floor, log, max, min, pow, sin, sqrt)`,
+		"r g":                `column 3: expected an operator or end of expression, found "g"`,
+		"1.2.3":              `column 1: invalid number "1.2.3"`,
+		"max() + 1":          `column 5: expected a number, variable, function or "(", found ")"`,
+		"é + r ∗ g":          `column 7: unexpected character '∗'`,
+		"0.3*r + 0.59*g + )": `column 18: expected a number, variable, function or "(", found ")"`,
+	} {
+		_, err := Compile(src)
+		require.Error(t, err, src)
+		assert.IsType(t, &SyntaxError{}, err, src)
+		assert.Equal(t, expected, err.Error(), src)
+	}
+}
+
+func TestCombineOrder(t *testing.T) {
+	t.Parallel()
+
+	cmb := MustCompile("r - b")
+
+	values := []color.Color{
+		color.NRGBA{B: 0xff, A: 0xff},
+		color.NRGBA{R: 0x40, B: 0x80, A: 0xff},
+		color.NRGBA{A: 0xff},
+		color.NRGBA{R: 0x80, A: 0xff},
+		color.NRGBA{R: 0xff, A: 0xff},
+	}
+
+	for i := 1; i < len(values); i++ {
+		assert.True(t, cmb.Combine(values[i-1]) < cmb.Combine(values[i]), "%v < %v", values[i-1], values[i])
+	}
+
+	assert.Panics(t, func() { MustCompile("r +") })
+	assert.Equal(t, "expression (r - b)", cmb.Name())
+	assert.False(t, math.IsNaN(cmb.(*expression).eval(color.Transparent)))
+}
+
+func TestConformance(t *testing.T) {
+	t.Parallel()
+
+	for _, cmb := range []combiner.Combiner{
+		MustCompile("0.3*r + 0.59*g + 0.11*b"),
+		MustCompile("a * (h / 360)"),
+		MustCompile("sqrt(-r)"),
+	} {
+		cmb := cmb
+		t.Run(cmb.Name(), func(t *testing.T) {
+			combinertest.Run(t, cmb)
+		})
+	}
+}
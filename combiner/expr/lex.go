@@ -0,0 +1,111 @@
+package expr
+
+import (
+	"fmt"
+	"strconv"
+	"unicode"
+	"unicode/utf8"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokNumber
+	tokIdent
+	tokOperator
+	tokLParen
+	tokRParen
+	tokComma
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	num  float64
+
+	// col is the 1-based column the token starts at
+	col int
+}
+
+func (t token) String() string {
+	if t.kind == tokEOF {
+		return "end of expression"
+	}
+
+	return strconv.Quote(t.text)
+}
+
+// lex splits src into tokens, ending with a tokEOF.
+func lex(src string) ([]token, error) {
+	var tokens []token
+
+	for i := 0; ; {
+		col := utf8.RuneCountInString(src[:i]) + 1
+		if i >= len(src) {
+			return append(tokens, token{kind: tokEOF, col: col}), nil
+		}
+
+		r, size := utf8.DecodeRuneInString(src[i:])
+		start := i
+		i += size
+
+		switch {
+		case unicode.IsSpace(r):
+			continue
+
+		case r == '(':
+			tokens = append(tokens, token{kind: tokLParen, text: "(", col: col})
+
+		case r == ')':
+			tokens = append(tokens, token{kind: tokRParen, text: ")", col: col})
+
+		case r == ',':
+			tokens = append(tokens, token{kind: tokComma, text: ",", col: col})
+
+		case r == '+', r == '-', r == '*', r == '/', r == '%', r == '^':
+			tokens = append(tokens, token{kind: tokOperator, text: string(r), col: col})
+
+		case r == '.' || (r >= '0' && r <= '9'):
+			for i < len(src) && isNumberByte(src[i], src[i-1]) {
+				i++
+			}
+
+			text := src[start:i]
+			num, err := strconv.ParseFloat(text, 64)
+			if err != nil {
+				return nil, &SyntaxError{Col: col, Msg: fmt.Sprintf("invalid number %q", text)}
+			}
+
+			tokens = append(tokens, token{kind: tokNumber, text: text, num: num, col: col})
+
+		case unicode.IsLetter(r) || r == '_':
+			for i < len(src) {
+				r, size = utf8.DecodeRuneInString(src[i:])
+				if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' {
+					break
+				}
+
+				i += size
+			}
+
+			tokens = append(tokens, token{kind: tokIdent, text: src[start:i], col: col})
+
+		default:
+			return nil, &SyntaxError{Col: col, Msg: fmt.Sprintf("unexpected character %q", r)}
+		}
+	}
+}
+
+// isNumberByte reports whether c continues a number, given the byte before it.
+func isNumberByte(c, prev byte) bool {
+	switch {
+	case c >= '0' && c <= '9', c == '.', c == 'e', c == 'E':
+		return true
+	case c == '+' || c == '-':
+		// Only as the sign of an exponent
+		return prev == 'e' || prev == 'E'
+	}
+
+	return false
+}
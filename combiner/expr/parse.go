@@ -0,0 +1,287 @@
+package expr
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// node is a compiled piece of an expression
+type node func(v *vars) float64
+
+// variable describes a variable that expressions can refer to
+type variable struct {
+	get  func(v *vars) float64
+	need need
+}
+
+var variables = map[string]variable{
+	"r":  {func(v *vars) float64 { return v.r }, needRGBA},
+	"g":  {func(v *vars) float64 { return v.g }, needRGBA},
+	"b":  {func(v *vars) float64 { return v.b }, needRGBA},
+	"a":  {func(v *vars) float64 { return v.a }, needAlpha},
+	"h":  {func(v *vars) float64 { return v.h }, needHSV},
+	"s":  {func(v *vars) float64 { return v.s }, needHSV},
+	"v":  {func(v *vars) float64 { return v.v }, needHSV},
+	"l":  {func(v *vars) float64 { return v.l }, needHSL},
+	"pi": {func(*vars) float64 { return math.Pi }, 0},
+}
+
+// function describes a function that expressions can call. A negative arity means any number of
+// arguments (at least one).
+type function struct {
+	arity int
+	call  func(args []float64) float64
+}
+
+func unary(f func(float64) float64) function {
+	return function{1, func(args []float64) float64 { return f(args[0]) }}
+}
+
+func variadic(f func(float64, float64) float64) function {
+	return function{-1, func(args []float64) float64 {
+		result := args[0]
+		for _, arg := range args[1:] {
+			result = f(result, arg)
+		}
+
+		return result
+	}}
+}
+
+var functions = map[string]function{
+	"sqrt":  unary(math.Sqrt),
+	"abs":   unary(math.Abs),
+	"floor": unary(math.Floor),
+	"ceil":  unary(math.Ceil),
+	"exp":   unary(math.Exp),
+	"log":   unary(math.Log),
+	"sin":   unary(math.Sin),
+	"cos":   unary(math.Cos),
+	"pow":   {2, func(args []float64) float64 { return math.Pow(args[0], args[1]) }},
+	"min":   variadic(math.Min),
+	"max":   variadic(math.Max),
+}
+
+var binaryOperators = map[string]func(x, y float64) float64{
+	"+": func(x, y float64) float64 { return x + y },
+	"-": func(x, y float64) float64 { return x - y },
+	"*": func(x, y float64) float64 { return x * y },
+	"/": func(x, y float64) float64 { return x / y },
+	"%": math.Mod,
+	"^": math.Pow,
+}
+
+// parser is a recursive descent parser that compiles expressions as it goes. The grammar is:
+//
+//	expr    = term { ("+" | "-") term }
+//	term    = unary { ("*" | "/" | "%") unary }
+//	unary   = ("+" | "-") unary | power
+//	power   = primary [ "^" unary ]
+//	primary = number | ident | ident "(" expr { "," expr } ")" | "(" expr ")"
+type parser struct {
+	tokens []token
+	pos    int
+
+	// need is everything that the compiled expression needs to have converted
+	need need
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+
+	return t
+}
+
+func (p *parser) errorf(t token, format string, args ...interface{}) error {
+	return &SyntaxError{Col: t.col, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) isOperator(ops ...string) bool {
+	t := p.peek()
+	if t.kind != tokOperator {
+		return false
+	}
+
+	for _, op := range ops {
+		if t.text == op {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p *parser) binary(operand func() (node, error), ops ...string) (node, error) {
+	left, err := operand()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.isOperator(ops...) {
+		op := binaryOperators[p.next().text]
+
+		right, err := operand()
+		if err != nil {
+			return nil, err
+		}
+
+		l := left
+		left = func(v *vars) float64 { return op(l(v), right(v)) }
+	}
+
+	return left, nil
+}
+
+func (p *parser) expr() (node, error) {
+	return p.binary(p.term, "+", "-")
+}
+
+func (p *parser) term() (node, error) {
+	return p.binary(p.unary, "*", "/", "%")
+}
+
+func (p *parser) unary() (node, error) {
+	if p.isOperator("+", "-") {
+		negate := p.next().text == "-"
+
+		operand, err := p.unary()
+		if err != nil || !negate {
+			return operand, err
+		}
+
+		return func(v *vars) float64 { return -operand(v) }, nil
+	}
+
+	return p.power()
+}
+
+func (p *parser) power() (node, error) {
+	base, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+
+	if !p.isOperator("^") {
+		return base, nil
+	}
+
+	p.next()
+
+	// Right associative, so 2^3^2 is 2^(3^2)
+	exponent, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+
+	return func(v *vars) float64 { return math.Pow(base(v), exponent(v)) }, nil
+}
+
+func (p *parser) primary() (node, error) {
+	t := p.next()
+
+	switch t.kind {
+	case tokNumber:
+		num := t.num
+		return func(*vars) float64 { return num }, nil
+
+	case tokIdent:
+		if p.peek().kind == tokLParen {
+			return p.call(t)
+		}
+
+		variable, ok := variables[strings.ToLower(t.text)]
+		if !ok {
+			return nil, p.errorf(t, "unknown variable %s (expected one of %s)", t, variableNames())
+		}
+
+		p.need |= variable.need
+
+		return variable.get, nil
+
+	case tokLParen:
+		inner, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+
+		if closing := p.next(); closing.kind != tokRParen {
+			return nil, p.errorf(closing, "expected \")\" to close the \"(\" at column %d, found %s", t.col, closing)
+		}
+
+		return inner, nil
+	}
+
+	return nil, p.errorf(t, "expected a number, variable, function or \"(\", found %s", t)
+}
+
+func (p *parser) call(name token) (node, error) {
+	fn, ok := functions[strings.ToLower(name.text)]
+	if !ok {
+		return nil, p.errorf(name, "unknown function %s (expected one of %s)", name, functionNames())
+	}
+
+	open := p.next()
+
+	var args []node
+	for {
+		arg, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+
+		args = append(args, arg)
+
+		sep := p.next()
+		if sep.kind == tokRParen {
+			break
+		}
+
+		if sep.kind != tokComma {
+			return nil, p.errorf(sep, "expected \",\" or \")\" to close the \"(\" at column %d, found %s", open.col, sep)
+		}
+	}
+
+	if fn.arity >= 0 && len(args) != fn.arity {
+		return nil, p.errorf(name, "%s takes %d argument(s), but was given %d", name, fn.arity, len(args))
+	}
+
+	return func(v *vars) float64 {
+		values := make([]float64, len(args))
+		for i, arg := range args {
+			values[i] = arg(v)
+		}
+
+		return fn.call(values)
+	}, nil
+}
+
+func variableNames() string {
+	keys := make([]string, 0, len(variables))
+	for k := range variables {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return strings.Join(keys, ", ")
+}
+
+func functionNames() string {
+	keys := make([]string, 0, len(functions))
+	for k := range functions {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return strings.Join(keys, ", ")
+}
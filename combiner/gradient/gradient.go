@@ -0,0 +1,80 @@
+// Package gradient implements combiner.ImageCombiner using the magnitude of the luma gradient at
+// each pixel, as found by the Sobel operator. Pixels on edges sort after pixels in smooth areas.
+package gradient
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/dcormier/go-pixelsort/colorspace"
+	"github.com/dcormier/go-pixelsort/combiner"
+)
+
+// Combiner is an instance of this Combiner
+var Combiner = New()
+
+func init() {
+	combiner.Register("gradient", combiner.Static(Combiner))
+}
+
+var _ combiner.ImageCombiner = (*gradient)(nil)
+
+type gradient struct{}
+
+// New creates a combiner.ImageCombiner that uses the gradient magnitude
+func New() combiner.ImageCombiner {
+	return &gradient{}
+}
+
+func (*gradient) Name() string {
+	return "gradient"
+}
+
+func (*gradient) Metadata() combiner.Metadata {
+	return combiner.Metadata{
+		Description:  "Magnitude of the luma gradient (Sobel), which is highest on edges",
+		Max:          0xffff,
+		IgnoresAlpha: true,
+	}
+}
+
+// Combine gives 0, since a color on its own has no gradient
+func (*gradient) Combine(c color.Color) uint64 {
+	return 0
+}
+
+// maxMagnitude is the largest gradient magnitude the Sobel operator can give for luma in [0, 1]
+var maxMagnitude = math.Hypot(4, 4)
+
+func (*gradient) CombineAt(img image.Image, x, y int) uint64 {
+	bounds := img.Bounds()
+
+	// Luma of the 3x3 neighborhood, with the edges of the image extended outward
+	var l [3][3]float64
+	for dy := -1; dy <= 1; dy++ {
+		for dx := -1; dx <= 1; dx++ {
+			l[dy+1][dx+1] = colorspace.YCbCrOf(img.At(
+				clamp(x+dx, bounds.Min.X, bounds.Max.X-1),
+				clamp(y+dy, bounds.Min.Y, bounds.Max.Y-1),
+			)).Y
+		}
+	}
+
+	gx := (l[0][2] + 2*l[1][2] + l[2][2]) - (l[0][0] + 2*l[1][0] + l[2][0])
+	gy := (l[2][0] + 2*l[2][1] + l[2][2]) - (l[0][0] + 2*l[0][1] + l[0][2])
+
+	return uint64(math.Hypot(gx, gy)/maxMagnitude*0xffff + 0.5)
+}
+
+func clamp(v, min, max int) int {
+	if v < min {
+		return min
+	}
+
+	if v > max {
+		return max
+	}
+
+	return v
+}
@@ -0,0 +1,13 @@
+package gradient
+
+import (
+	"testing"
+
+	"github.com/dcormier/go-pixelsort/combiner/combinertest"
+)
+
+func TestConformance(t *testing.T) {
+	t.Parallel()
+
+	combinertest.Run(t, Combiner)
+}
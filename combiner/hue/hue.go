@@ -0,0 +1,61 @@
+// Package hue implements combiner.Combiner using the HSV hue of each color, measured in degrees
+// from an origin hue. Grays have a hue of 0.
+package hue
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+
+	"github.com/dcormier/go-pixelsort/colorspace"
+	"github.com/dcormier/go-pixelsort/combiner"
+)
+
+// Combiner is an instance of this Combiner, starting from red
+var Combiner = New(0)
+
+func init() {
+	combiner.Register("hue", func(p *combiner.Params) (combiner.Combiner, error) {
+		return New(p.FloatIn("origin", 0, -360, 360)), nil
+	})
+}
+
+// keyScale is the number of keys per degree of hue
+const keyScale = 1000
+
+var _ combiner.Combiner = (*hue)(nil)
+
+type hue struct {
+	origin float64
+}
+
+// New creates a combiner.Combiner that uses hue, in degrees, measured from origin
+func New(origin float64) combiner.Combiner {
+	return &hue{origin: origin}
+}
+
+func (h *hue) Name() string {
+	if h.origin == 0 {
+		return "hue"
+	}
+
+	return fmt.Sprintf("hue (from %v°)", h.origin)
+}
+
+func (h *hue) Metadata() combiner.Metadata {
+	return combiner.Metadata{
+		Description:  fmt.Sprintf("HSV hue, in degrees from %v°", h.origin),
+		Max:          360*keyScale - 1,
+		IgnoresAlpha: true,
+		Cyclic:       true,
+	}
+}
+
+func (h *hue) Combine(c color.Color) uint64 {
+	deg := math.Mod(colorspace.HSVOf(c).H-h.origin, 360)
+	if deg < 0 {
+		deg += 360
+	}
+
+	return uint64(deg * keyScale)
+}
@@ -0,0 +1,26 @@
+package combiner
+
+import (
+	"math"
+)
+
+// FloatKey maps a float64 onto a uint64 such that the keys sort in the same order as the floats
+// they came from, including negative numbers and infinities. NaN sorts before everything else.
+func FloatKey(f float64) uint64 {
+	if math.IsNaN(f) {
+		return 0
+	}
+
+	if f == 0 {
+		// Don't distinguish between -0 and +0
+		f = 0
+	}
+
+	bits := math.Float64bits(f)
+	if bits&(1<<63) != 0 {
+		// Negative numbers sort in reverse order of their magnitude
+		return ^bits
+	}
+
+	return bits | 1<<63
+}
@@ -0,0 +1,35 @@
+package combiner
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFloatKey(t *testing.T) {
+	t.Parallel()
+
+	ordered := []float64{
+		math.Inf(-1),
+		-math.MaxFloat64,
+		-1e10,
+		-1,
+		-math.SmallestNonzeroFloat64,
+		0,
+		math.SmallestNonzeroFloat64,
+		0.5,
+		1,
+		1e10,
+		math.MaxFloat64,
+		math.Inf(1),
+	}
+
+	assert.True(t, FloatKey(math.NaN()) < FloatKey(ordered[0]))
+
+	for i := 1; i < len(ordered); i++ {
+		assert.True(t, FloatKey(ordered[i-1]) < FloatKey(ordered[i]), "%v < %v", ordered[i-1], ordered[i])
+	}
+
+	assert.Equal(t, FloatKey(0), FloatKey(math.Copysign(0, -1)))
+}
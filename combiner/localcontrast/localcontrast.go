@@ -0,0 +1,91 @@
+// Package localcontrast implements combiner.ImageCombiner using the local contrast around each
+// pixel: the standard deviation of luma in the square neighborhood centered on it.
+// Pixels in flat areas sort before pixels in busy, detailed areas.
+package localcontrast
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/dcormier/go-pixelsort/colorspace"
+	"github.com/dcormier/go-pixelsort/combiner"
+)
+
+// Combiner is an instance of this Combiner, using a 5x5 neighborhood
+var Combiner = New(2)
+
+func init() {
+	combiner.Register("localcontrast", func(p *combiner.Params) (combiner.Combiner, error) {
+		return New(p.IntIn("radius", 2, 1, 32)), nil
+	})
+}
+
+var _ combiner.ImageCombiner = (*localContrast)(nil)
+
+type localContrast struct {
+	radius int
+}
+
+// New creates a combiner.ImageCombiner that measures contrast in the neighborhood within radius
+// pixels of each pixel
+func New(radius int) combiner.ImageCombiner {
+	return &localContrast{radius: radius}
+}
+
+func (lc *localContrast) Name() string {
+	if lc.radius == 2 {
+		return "local contrast"
+	}
+
+	return fmt.Sprintf("local contrast (radius %d)", lc.radius)
+}
+
+func (lc *localContrast) Metadata() combiner.Metadata {
+	size := 2*lc.radius + 1
+
+	return combiner.Metadata{
+		Description:  fmt.Sprintf("Standard deviation of luma in the surrounding %dx%d pixels", size, size),
+		Max:          0xffff,
+		IgnoresAlpha: true,
+	}
+}
+
+// Combine gives 0, since a color on its own has no contrast
+func (*localContrast) Combine(c color.Color) uint64 {
+	return 0
+}
+
+func (lc *localContrast) CombineAt(img image.Image, x, y int) uint64 {
+	bounds := img.Bounds()
+
+	var sum, sumSq, n float64
+
+	for ny := y - lc.radius; ny <= y+lc.radius; ny++ {
+		if ny < bounds.Min.Y || ny >= bounds.Max.Y {
+			continue
+		}
+
+		for nx := x - lc.radius; nx <= x+lc.radius; nx++ {
+			if nx < bounds.Min.X || nx >= bounds.Max.X {
+				continue
+			}
+
+			luma := colorspace.YCbCrOf(img.At(nx, ny)).Y
+			sum += luma
+			sumSq += luma * luma
+			n++
+		}
+	}
+
+	if n == 0 {
+		return 0
+	}
+
+	mean := sum / n
+	stdDev := math.Sqrt(math.Max(0, sumSq/n-mean*mean))
+
+	// The standard deviation of values in [0, 1] is at most 0.5
+	return uint64(stdDev*2*0xffff + 0.5)
+}
@@ -0,0 +1,66 @@
+package combiner
+
+import (
+	"math"
+)
+
+// Metadata describes a Combiner and the values it gives.
+type Metadata struct {
+	// Description is a short, human readable description of the Combiner.
+	Description string
+
+	// Min and Max are the smallest and largest values that the Combiner can give.
+	Min, Max uint64
+
+	// IgnoresAlpha is true if colors that differ only in alpha are given the same value (apart from
+	// rounding errors that come from alpha-premultiplication, and fully transparent colors, which
+	// have no color left to go on).
+	IgnoresAlpha bool
+
+	// Cyclic is true if the values wrap around (as hue does), so that Min and Max are neighbors.
+	Cyclic bool
+}
+
+// Describer is implemented by Combiners that can describe themselves. It's optional; see
+// MetadataOf.
+type Describer interface {
+	Metadata() Metadata
+}
+
+// MetadataOf returns the Metadata of c. If c isn't a Describer, nothing is known about it, so it
+// could give any value at all.
+func MetadataOf(c Combiner) Metadata {
+	if d, ok := c.(Describer); ok {
+		return d.Metadata()
+	}
+
+	return Metadata{Max: math.MaxUint64}
+}
+
+// Key maps fraction (in [0, 1]) onto the range of values, so that 0 is Min and 1 is Max.
+// Fractions outside of [0, 1] are clamped.
+func (m Metadata) Key(fraction float64) uint64 {
+	if fraction <= 0 || math.IsNaN(fraction) {
+		return m.Min
+	}
+
+	if fraction >= 1 {
+		return m.Max
+	}
+
+	return m.Min + uint64(fraction*float64(m.Max-m.Min))
+}
+
+// Fraction maps key onto [0, 1], so that Min is 0 and Max is 1. Keys outside of [Min, Max] are
+// clamped.
+func (m Metadata) Fraction(key uint64) float64 {
+	if key <= m.Min || m.Max <= m.Min {
+		return 0
+	}
+
+	if key >= m.Max {
+		return 1
+	}
+
+	return float64(key-m.Min) / float64(m.Max-m.Min)
+}
@@ -0,0 +1,63 @@
+package combiner
+
+import (
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type undescribed struct{}
+
+func (undescribed) Name() string                 { return "undescribed" }
+func (undescribed) Combine(c color.Color) uint64 { return 0 }
+
+type described struct {
+	undescribed
+}
+
+func (described) Metadata() Metadata {
+	return Metadata{Description: "described", Min: 100, Max: 200, Cyclic: true}
+}
+
+func TestMetadataOf(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, Metadata{Max: math.MaxUint64}, MetadataOf(undescribed{}))
+	assert.Equal(t, Metadata{Description: "described", Min: 100, Max: 200, Cyclic: true}, MetadataOf(described{}))
+}
+
+func TestMetadataKeyFraction(t *testing.T) {
+	t.Parallel()
+
+	m := Metadata{Min: 100, Max: 200}
+
+	for fraction, key := range map[float64]uint64{
+		-1:   100,
+		0:    100,
+		0.25: 125,
+		0.5:  150,
+		1:    200,
+		2:    200,
+	} {
+		assert.Equal(t, key, m.Key(fraction), "Key(%v)", fraction)
+	}
+
+	for key, fraction := range map[uint64]float64{
+		0:   0,
+		100: 0,
+		125: 0.25,
+		200: 1,
+		300: 1,
+	} {
+		assert.Equal(t, fraction, m.Fraction(key), "Fraction(%v)", key)
+	}
+
+	full := Metadata{Max: math.MaxUint64}
+	assert.Equal(t, uint64(0), full.Key(0))
+	assert.Equal(t, uint64(math.MaxUint64), full.Key(1))
+	assert.InDelta(t, 0.5, full.Fraction(full.Key(0.5)), 1e-9)
+
+	assert.Equal(t, float64(0), Metadata{}.Fraction(10))
+}
@@ -0,0 +1,105 @@
+// Package noise implements combiner.ImageCombiner using luma that has been modulated by smooth
+// value noise, so that otherwise even areas break up into organic looking patches.
+package noise
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/dcormier/go-pixelsort/colorspace"
+	"github.com/dcormier/go-pixelsort/combiner"
+)
+
+// Combiner is an instance of this Combiner
+var Combiner = New(32, 0.5, 0)
+
+func init() {
+	combiner.Register("noise", func(p *combiner.Params) (combiner.Combiner, error) {
+		return New(
+			p.FloatIn("scale", 32, 1, 1<<16),
+			p.FloatIn("strength", 0.5, 0, 1),
+			int64(p.Int("seed", 0)),
+		), nil
+	})
+}
+
+var _ combiner.ImageCombiner = (*noise)(nil)
+
+type noise struct {
+	scale    float64
+	strength float64
+	seed     int64
+}
+
+// New creates a combiner.ImageCombiner that modulates luma with noise. scale is the size of the
+// noise features in pixels, strength (in [0, 1]) is how much the noise affects the luma and seed
+// picks the noise pattern.
+func New(scale, strength float64, seed int64) combiner.ImageCombiner {
+	return &noise{scale: scale, strength: strength, seed: seed}
+}
+
+func (n *noise) Name() string {
+	if n.scale == 32 && n.strength == 0.5 && n.seed == 0 {
+		return "noise"
+	}
+
+	return fmt.Sprintf("noise (scale %v, strength %v, seed %d)", n.scale, n.strength, n.seed)
+}
+
+func (n *noise) Metadata() combiner.Metadata {
+	return combiner.Metadata{
+		Description: fmt.Sprintf("Luma, modulated by value noise (scale %v, strength %v, seed %d)",
+			n.scale, n.strength, n.seed),
+		Max:          0xffff,
+		IgnoresAlpha: true,
+	}
+}
+
+// Combine gives the luma of c, without any noise
+func (*noise) Combine(c color.Color) uint64 {
+	return uint64(colorspace.YCbCrOf(c).Y*0xffff + 0.5)
+}
+
+func (n *noise) CombineAt(img image.Image, x, y int) uint64 {
+	luma := colorspace.YCbCrOf(img.At(x, y)).Y
+	v := n.valueNoise(float64(x)/n.scale, float64(y)/n.scale)
+
+	return uint64(luma*(1-n.strength+n.strength*v)*0xffff + 0.5)
+}
+
+// valueNoise gives smoothly interpolated noise in [0, 1]
+func (n *noise) valueNoise(x, y float64) float64 {
+	x0, y0 := math.Floor(x), math.Floor(y)
+	ix, iy := int64(x0), int64(y0)
+
+	tx := smoothstep(x - x0)
+	ty := smoothstep(y - y0)
+
+	top := lerp(n.lattice(ix, iy), n.lattice(ix+1, iy), tx)
+	bottom := lerp(n.lattice(ix, iy+1), n.lattice(ix+1, iy+1), tx)
+
+	return lerp(top, bottom, ty)
+}
+
+// lattice gives a pseudo-random value in [0, 1] for a point on the integer lattice
+func (n *noise) lattice(x, y int64) float64 {
+	// SplitMix64 finalizer (http://xoshiro.di.unimi.it/splitmix64.c)
+	h := uint64(x)*0x9e3779b97f4a7c15 ^ uint64(y)*0xc2b2ae3d27d4eb4f ^ uint64(n.seed)*0x165667b19e3779f9
+	h ^= h >> 30
+	h *= 0xbf58476d1ce4e5b9
+	h ^= h >> 27
+	h *= 0x94d049bb133111eb
+	h ^= h >> 31
+
+	return float64(h>>11) / (1 << 53)
+}
+
+func smoothstep(t float64) float64 {
+	return t * t * (3 - 2*t)
+}
+
+func lerp(a, b, t float64) float64 {
+	return a + (b-a)*t
+}
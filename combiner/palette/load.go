@@ -0,0 +1,170 @@
+package palette
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif" // For loading palettes from images
+	_ "image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/dcormier/go-pixelsort/colorspace"
+)
+
+// MaxImageColors is the most distinct colors that a palette can be built from, from an image that
+// isn't paletted
+const MaxImageColors = 256
+
+// Load reads a palette from a file. Files ending in .gpl are read as GIMP palettes and files ending
+// in .hex or .txt are read as one hex color per line. Anything else is decoded as an image (see
+// FromImage); only the image formats registered with the image package can be read.
+func Load(path string) (color.Palette, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".gpl":
+		return ParseGPL(f)
+
+	case ".hex", ".txt":
+		return ParseHex(f)
+	}
+
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("reading palette from %s: %v", path, err)
+	}
+
+	return FromImage(img)
+}
+
+// ParseGPL reads a GIMP palette, which looks like:
+//
+//	GIMP Palette
+//	Name: Brand
+//	Columns: 4
+//	#
+//	  0  80 255	Brand blue
+//	255 255 255	White
+func ParseGPL(r io.Reader) (color.Palette, error) {
+	scanner := bufio.NewScanner(r)
+
+	if !scanner.Scan() || strings.TrimSpace(scanner.Text()) != "GIMP Palette" {
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+
+		return nil, errors.New(`not a GIMP palette: it doesn't start with "GIMP Palette"`)
+	}
+
+	var p color.Palette
+
+	for line := 2; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+
+		if text == "" || strings.HasPrefix(text, "#") ||
+			strings.HasPrefix(text, "Name:") || strings.HasPrefix(text, "Columns:") {
+			continue
+		}
+
+		fields := strings.Fields(text)
+		if len(fields) < 3 {
+			return nil, fmt.Errorf("line %d: expected red, green and blue values, found %q", line, text)
+		}
+
+		var rgb [3]uint8
+		for i := range rgb {
+			v, err := strconv.ParseUint(fields[i], 10, 8)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid channel value %q", line, fields[i])
+			}
+
+			rgb[i] = uint8(v)
+		}
+
+		p = append(p, color.NRGBA{R: rgb[0], G: rgb[1], B: rgb[2], A: 0xff})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(p) == 0 {
+		return nil, errors.New("the palette has no colors")
+	}
+
+	return p, nil
+}
+
+// ParseHex reads a palette with one hex color (such as "0050ff" or "#0050ff") per line. Blank
+// lines and lines starting with ";" are skipped.
+func ParseHex(r io.Reader) (color.Palette, error) {
+	scanner := bufio.NewScanner(r)
+
+	var p color.Palette
+
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, ";") {
+			continue
+		}
+
+		c, err := colorspace.ParseHex(text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+
+		p = append(p, c)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(p) == 0 {
+		return nil, errors.New("the palette has no colors")
+	}
+
+	return p, nil
+}
+
+// FromImage gives the palette of an image. For an *image.Paletted, that's its own palette. For
+// anything else, it's the distinct colors of the image in the order they first appear (reading
+// left to right, top to bottom), as long as there are no more than MaxImageColors of them.
+func FromImage(img image.Image) (color.Palette, error) {
+	if paletted, ok := img.(*image.Paletted); ok {
+		return paletted.Palette, nil
+	}
+
+	var p color.Palette
+	seen := map[color.NRGBA64]bool{}
+
+	bounds := img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := color.NRGBA64Model.Convert(img.At(x, y)).(color.NRGBA64)
+			if seen[c] {
+				continue
+			}
+
+			if len(p) == MaxImageColors {
+				return nil, fmt.Errorf("the image has more than %d colors", MaxImageColors)
+			}
+
+			seen[c] = true
+			p = append(p, c)
+		}
+	}
+
+	return p, nil
+}
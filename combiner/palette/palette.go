@@ -0,0 +1,196 @@
+// Package palette implements combiner.ImageCombiner by quantizing each pixel to the nearest color
+// in a palette, and keying on the index of that color, so that sorting puts an image into the
+// palette's order. Pixels that map to the same palette entry are ordered by how close they are to
+// it. Nearness is measured in CIE L*a*b*, without alpha-premultiplication.
+//
+// Palettes can be loaded from GIMP (.gpl) files, from .hex files (one hex color per line) and from
+// images (see Load).
+//
+// For *image.Paletted sources, the work is done once per entry in the image's own palette, rather
+// than once per pixel. If no palette is given at all, the image's own palette is the order.
+package palette
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"strings"
+	"sync/atomic"
+
+	"github.com/dcormier/go-pixelsort/colorspace"
+	"github.com/dcormier/go-pixelsort/combiner"
+)
+
+// Combiner is an instance of this Combiner that uses the palettes of *image.Paletted sources
+var Combiner = New(nil)
+
+func init() {
+	combiner.Register("palette", func(p *combiner.Params) (combiner.Combiner, error) {
+		file := p.String("file", "")
+		colors := p.String("colors", "")
+
+		if file != "" && colors != "" {
+			p.Errorf("colors", "only one of file and colors can be given")
+		}
+
+		if p.Err() != nil {
+			return nil, p.Err()
+		}
+
+		var pal color.Palette
+
+		switch {
+		case file != "":
+			var err error
+			if pal, err = Load(file); err != nil {
+				p.Errorf("file", "%v", err)
+				return nil, p.Err()
+			}
+
+		case colors != "":
+			for _, hex := range strings.Split(colors, ",") {
+				c, err := colorspace.ParseHex(strings.TrimSpace(hex))
+				if err != nil {
+					p.Errorf("colors", "%v", err)
+					return nil, p.Err()
+				}
+
+				pal = append(pal, c)
+			}
+		}
+
+		return New(pal), nil
+	})
+}
+
+// distanceScale is what distances are multiplied by before being put in the low 32 bits of a key.
+// Distances in L*a*b* between colors in the sRGB gamut are well under 2^12.
+const distanceScale = 1 << 20
+
+var _ combiner.ImageCombiner = (*paletteOrder)(nil)
+
+type paletteOrder struct {
+	palette color.Palette
+	labs    []colorspace.Lab
+
+	// src caches the *sourceKeys of the most recently seen *image.Paletted, so that CombineAt
+	// doesn't have to lock anything for each pixel
+	src atomic.Value
+}
+
+// sourceKeys are the keys for each entry in the palette of a source image
+type sourceKeys struct {
+	img     *image.Paletted
+	palette color.Palette
+	keys    []uint64
+}
+
+// New creates a combiner.ImageCombiner that sorts into the order of p. If p is empty, the palettes
+// of *image.Paletted sources are used instead, and other sources all get the same value.
+func New(p color.Palette) combiner.ImageCombiner {
+	po := &paletteOrder{palette: p, labs: make([]colorspace.Lab, len(p))}
+
+	for i, c := range p {
+		po.labs[i] = colorspace.LabOf(c)
+	}
+
+	return po
+}
+
+func (po *paletteOrder) Name() string {
+	if len(po.palette) == 0 {
+		return "palette order"
+	}
+
+	return fmt.Sprintf("palette order (%d colors)", len(po.palette))
+}
+
+func (po *paletteOrder) Metadata() combiner.Metadata {
+	md := combiner.Metadata{
+		Description:  "Index of the nearest color in the image's own palette",
+		IgnoresAlpha: true,
+		Max:          math.MaxUint32 << 32,
+	}
+
+	if len(po.palette) > 0 {
+		md.Description = fmt.Sprintf("Index of the nearest color in a %d color palette, then distance from it",
+			len(po.palette))
+		md.Max = uint64(len(po.palette)-1)<<32 | math.MaxUint32
+	}
+
+	return md
+}
+
+// Combine gives the key for c quantized to the palette. Without a palette, every color gets 0.
+func (po *paletteOrder) Combine(c color.Color) uint64 {
+	if len(po.palette) == 0 {
+		return 0
+	}
+
+	lab := colorspace.LabOf(c)
+
+	best, bestDist := 0, math.Inf(1)
+	for i, entry := range po.labs {
+		if dist := colorspace.DeltaE76(lab, entry); dist < bestDist {
+			best, bestDist = i, dist
+		}
+	}
+
+	dist := uint64(bestDist*distanceScale + 0.5)
+	if dist > math.MaxUint32 {
+		dist = math.MaxUint32
+	}
+
+	return uint64(best)<<32 | dist
+}
+
+func (po *paletteOrder) CombineAt(img image.Image, x, y int) uint64 {
+	paletted, ok := img.(*image.Paletted)
+	if !ok {
+		return po.Combine(img.At(x, y))
+	}
+
+	idx := paletted.ColorIndexAt(x, y)
+
+	if len(po.palette) == 0 {
+		return uint64(idx) << 32
+	}
+
+	// An index past the end of the palette has no color (and paletted.At would panic), so it's
+	// taken to be transparent black
+	keys := po.sourceKeys(paletted)
+	if int(idx) >= len(keys) {
+		return po.Combine(color.Transparent)
+	}
+
+	return keys[idx]
+}
+
+// sourceKeys gives the key for each entry in the palette of img. They're worked out once for each
+// image (and again if its palette is replaced), rather than once for each pixel.
+func (po *paletteOrder) sourceKeys(img *image.Paletted) []uint64 {
+	if cached, _ := po.src.Load().(*sourceKeys); cached != nil && cached.img == img &&
+		samePalette(cached.palette, img.Palette) {
+		return cached.keys
+	}
+
+	// Goroutines that get here at the same time for the same image all work out the same keys
+	keys := make([]uint64, len(img.Palette))
+	for i, c := range img.Palette {
+		keys[i] = po.Combine(c)
+	}
+
+	po.src.Store(&sourceKeys{img: img, palette: img.Palette, keys: keys})
+
+	return keys
+}
+
+// samePalette is whether a and b are the same slice, rather than just having the same colors
+func samePalette(a, b color.Palette) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	return len(a) == 0 || &a[0] == &b[0]
+}
@@ -0,0 +1,164 @@
+package palette
+
+import (
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dcormier/go-pixelsort/combiner"
+	"github.com/dcormier/go-pixelsort/combiner/combinertest"
+)
+
+var (
+	red   = color.NRGBA{R: 0xff, A: 0xff}
+	green = color.NRGBA{G: 0xff, A: 0xff}
+	blue  = color.NRGBA{B: 0xff, A: 0xff}
+)
+
+func TestCombine(t *testing.T) {
+	t.Parallel()
+
+	cmb := New(color.Palette{blue, red, green})
+
+	assert.Equal(t, "palette order (3 colors)", cmb.Name())
+
+	// Exact matches have no distance
+	assert.Equal(t, uint64(0)<<32, cmb.Combine(blue))
+	assert.Equal(t, uint64(1)<<32, cmb.Combine(red))
+	assert.Equal(t, uint64(2)<<32, cmb.Combine(green))
+
+	// Near matches sort after the exact match, but before the next entry
+	darkRed := color.NRGBA{R: 0xc0, A: 0xff}
+	darkerRed := color.NRGBA{R: 0x80, A: 0xff}
+
+	assert.True(t, cmb.Combine(red) < cmb.Combine(darkRed))
+	assert.True(t, cmb.Combine(darkRed) < cmb.Combine(darkerRed))
+	assert.True(t, cmb.Combine(darkerRed) < cmb.Combine(green))
+	assert.Equal(t, uint64(1), cmb.Combine(darkerRed)>>32)
+
+	assert.True(t, cmb.Combine(darkerRed) <= combiner.MetadataOf(cmb).Max)
+
+	// Without a palette, everything is equal
+	assert.Equal(t, uint64(0), Combiner.Combine(red))
+}
+
+func TestCombineAtPaletted(t *testing.T) {
+	t.Parallel()
+
+	img := image.NewPaletted(image.Rect(0, 0, 3, 1), color.Palette{green, blue, red})
+	img.SetColorIndex(0, 0, 2)
+	img.SetColorIndex(1, 0, 0)
+	img.SetColorIndex(2, 0, 1)
+
+	// The image's own palette
+	assert.Equal(t, uint64(2)<<32, Combiner.CombineAt(img, 0, 0))
+	assert.Equal(t, uint64(0)<<32, Combiner.CombineAt(img, 1, 0))
+	assert.Equal(t, uint64(1)<<32, Combiner.CombineAt(img, 2, 0))
+
+	// A different palette
+	cmb := New(color.Palette{red, green, blue})
+	for x := 0; x < 3; x++ {
+		assert.Equal(t, cmb.Combine(img.At(x, 0)), cmb.CombineAt(img, x, 0), "x=%d", x)
+	}
+
+	// Each image's own palette is used, even when they're interleaved, or one is replaced
+	other := image.NewPaletted(img.Bounds(), color.Palette{blue, green, red})
+	assert.Equal(t, cmb.Combine(blue), cmb.CombineAt(other, 0, 0))
+	assert.Equal(t, cmb.Combine(red), cmb.CombineAt(img, 0, 0))
+
+	other.Palette = color.Palette{green}
+	assert.Equal(t, cmb.Combine(green), cmb.CombineAt(other, 0, 0))
+
+	// An index past the end of the image's palette is taken to be transparent black
+	img.Pix[0] = 7
+	assert.Equal(t, cmb.Combine(color.Transparent), cmb.CombineAt(img, 0, 0))
+}
+
+func TestParseGPL(t *testing.T) {
+	t.Parallel()
+
+	p, err := ParseGPL(strings.NewReader(`GIMP Palette
+Name: Test
+Columns: 2
+#
+255   0   0	Red
+  0   0 255	Blue
+
+`))
+	require.NoError(t, err)
+	assert.Equal(t, color.Palette{red, blue}, p)
+
+	_, err = ParseGPL(strings.NewReader("ff0000\n"))
+	assert.Error(t, err)
+
+	_, err = ParseGPL(strings.NewReader("GIMP Palette\n255 0\n"))
+	assert.EqualError(t, err, `line 2: expected red, green and blue values, found "255 0"`)
+
+	_, err = ParseGPL(strings.NewReader("GIMP Palette\n256 0 0\n"))
+	assert.EqualError(t, err, `line 2: invalid channel value "256"`)
+}
+
+func TestParseHex(t *testing.T) {
+	t.Parallel()
+
+	p, err := ParseHex(strings.NewReader("; comment\nff0000\n\n#00ff00\n"))
+	require.NoError(t, err)
+	assert.Equal(t, color.Palette{red, green}, p)
+
+	_, err = ParseHex(strings.NewReader("\n"))
+	assert.Error(t, err)
+
+	_, err = ParseHex(strings.NewReader("ff0000\nnope\n"))
+	assert.Error(t, err)
+}
+
+func TestFromImage(t *testing.T) {
+	t.Parallel()
+
+	img := image.NewNRGBA(image.Rect(1, 1, 4, 2))
+	img.Set(1, 1, blue)
+	img.Set(2, 1, red)
+	img.Set(3, 1, blue)
+
+	p, err := FromImage(img)
+	require.NoError(t, err)
+	require.Len(t, p, 2)
+	assert.Equal(t, color.NRGBA64Model.Convert(blue), p[0])
+	assert.Equal(t, color.NRGBA64Model.Convert(red), p[1])
+}
+
+func TestSpec(t *testing.T) {
+	t.Parallel()
+
+	cmb, err := combiner.Parse("palette?colors=ff0000,#0000ff")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1)<<32, cmb.Combine(blue))
+
+	cmb, err = combiner.Parse("palette")
+	require.NoError(t, err)
+	assert.Equal(t, "palette order", cmb.Name())
+
+	_, err = combiner.Parse("palette?colors=nope")
+	assert.Error(t, err)
+
+	_, err = combiner.Parse("palette?file=does-not-exist.gpl")
+	assert.Error(t, err)
+}
+
+func TestConformance(t *testing.T) {
+	t.Parallel()
+
+	for _, cmb := range []combiner.Combiner{
+		Combiner,
+		New(color.Palette{color.Black, color.White, color.NRGBA{R: 0xff, A: 0xff}}),
+	} {
+		cmb := cmb
+		t.Run(cmb.Name(), func(t *testing.T) {
+			combinertest.Run(t, cmb)
+		})
+	}
+}
@@ -12,6 +12,10 @@ import (
 // Combiner is an instance of this Combiner
 var Combiner = New()
 
+func init() {
+	combiner.Register("perceivedoption1", combiner.Static(Combiner))
+}
+
 var _ combiner.Combiner = (*perceivedOption1)(nil)
 
 type perceivedOption1 struct{}
@@ -25,10 +29,17 @@ func (*perceivedOption1) Name() string {
 	return "perceived (option 1)"
 }
 
+func (*perceivedOption1) Metadata() combiner.Metadata {
+	return combiner.Metadata{
+		Description: "Perceived brightness, using BT.601 luma weights on a white background",
+		Max:         combiner.AlphaBlendMax,
+	}
+}
+
 func (*perceivedOption1) Combine(c color.Color) uint64 {
 	r, g, b, a := c.RGBA()
 
-	return uint64(combiner.AlphaBlend(r, a, combiner.CMax)*0.299 +
-		combiner.AlphaBlend(g, a, combiner.CMax)*0.587 +
-		combiner.AlphaBlend(b, a, combiner.CMax)*0.114)
+	return combiner.ClampBlended(combiner.AlphaBlend(r, a, combiner.CMax)*0.299+
+		combiner.AlphaBlend(g, a, combiner.CMax)*0.587+
+		combiner.AlphaBlend(b, a, combiner.CMax)*0.114, combiner.AlphaBlendMax)
 }
@@ -13,6 +13,13 @@ import (
 // Combiner is an instance of this Combiner
 var Combiner = New()
 
+func init() {
+	combiner.Register("perceivedoption2", combiner.Static(Combiner))
+}
+
+// weightMagnitude is the length of the vector of channel weights
+var weightMagnitude = math.Sqrt(0.241*0.241 + 0.691*0.691 + 0.068*0.068)
+
 var _ combiner.Combiner = (*perceivedOption2)(nil)
 
 type perceivedOption2 struct{}
@@ -26,6 +33,13 @@ func (*perceivedOption2) Name() string {
 	return "perceived (option 2)"
 }
 
+func (*perceivedOption2) Metadata() combiner.Metadata {
+	return combiner.Metadata{
+		Description: "Perceived brightness, using the HSP color model on a white background",
+		Max:         uint64(weightMagnitude * combiner.AlphaBlendMax),
+	}
+}
+
 func (*perceivedOption2) Combine(c color.Color) uint64 {
 	r, g, b, a := c.RGBA()
 
@@ -13,6 +13,13 @@ import (
 // Combiner is an instance of this Combiner
 var Combiner = New()
 
+func init() {
+	combiner.Register("perceivedoption2noalpha", combiner.Static(Combiner))
+}
+
+// weightMagnitude is the length of the vector of channel weights
+var weightMagnitude = math.Sqrt(0.241*0.241 + 0.691*0.691 + 0.068*0.068)
+
 var _ combiner.Combiner = (*perceivedOption2NoAlpha)(nil)
 
 type perceivedOption2NoAlpha struct{}
@@ -26,6 +33,13 @@ func (*perceivedOption2NoAlpha) Name() string {
 	return "perceived (option 2, no alpha)"
 }
 
+func (*perceivedOption2NoAlpha) Metadata() combiner.Metadata {
+	return combiner.Metadata{
+		Description: "Perceived brightness, using the HSP color model on alpha-premultiplied channels",
+		Max:         uint64(weightMagnitude * 0xffff),
+	}
+}
+
 func (*perceivedOption2NoAlpha) Combine(c color.Color) uint64 {
 	r, g, b, _ := c.RGBA()
 
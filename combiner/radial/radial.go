@@ -0,0 +1,69 @@
+// Package radial implements combiner.ImageCombiner using the distance of each pixel from a point
+// in the image, ignoring its color entirely. Sorting by it gathers pixels into rings around the
+// point.
+package radial
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/dcormier/go-pixelsort/combiner"
+)
+
+// Combiner is an instance of this Combiner, measuring from the center of the image
+var Combiner = New(0.5, 0.5)
+
+func init() {
+	combiner.Register("radial", func(p *combiner.Params) (combiner.Combiner, error) {
+		return New(p.Float("x", 0.5), p.Float("y", 0.5)), nil
+	})
+}
+
+// keyScale is the number of keys per pixel of distance
+const keyScale = 256
+
+var _ combiner.ImageCombiner = (*radial)(nil)
+
+type radial struct {
+	x, y float64
+}
+
+// New creates a combiner.ImageCombiner that measures distance from the point (x, y), given as
+// fractions of the image's width and height. (0, 0) is the top left of the image and (1, 1) is
+// the bottom right.
+func New(x, y float64) combiner.ImageCombiner {
+	return &radial{x: x, y: y}
+}
+
+func (r *radial) Name() string {
+	if r.x == 0.5 && r.y == 0.5 {
+		return "radial"
+	}
+
+	return fmt.Sprintf("radial (from %v, %v)", r.x, r.y)
+}
+
+func (r *radial) Metadata() combiner.Metadata {
+	return combiner.Metadata{
+		Description: fmt.Sprintf("Distance from the point (%v, %v) of the image, ignoring color", r.x, r.y),
+		// It depends on the size of the image
+		Max:          math.MaxUint64,
+		IgnoresAlpha: true,
+	}
+}
+
+// Combine gives 0, since a color on its own has no position
+func (*radial) Combine(c color.Color) uint64 {
+	return 0
+}
+
+func (r *radial) CombineAt(img image.Image, x, y int) uint64 {
+	bounds := img.Bounds()
+
+	cx := float64(bounds.Min.X) + r.x*float64(bounds.Dx()-1)
+	cy := float64(bounds.Min.Y) + r.y*float64(bounds.Dy()-1)
+
+	return uint64(math.Hypot(float64(x)-cx, float64(y)-cy)*keyScale + 0.5)
+}
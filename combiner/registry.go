@@ -0,0 +1,125 @@
+package combiner
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Factory creates a Combiner configured by the parameters of a spec. It reads its parameters
+// through p, and can leave reporting bad parameter values to p (see Params).
+type Factory func(p *Params) (Combiner, error)
+
+// Static returns a Factory for a Combiner that doesn't take any parameters.
+func Static(c Combiner) Factory {
+	return func(*Params) (Combiner, error) {
+		return c, nil
+	}
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Factory{}
+)
+
+// Register makes a Combiner available to Parse under the given name. Combiner packages call this
+// from their init functions; importing github.com/dcormier/go-pixelsort/combiner/all registers
+// all of the built-in Combiners. Register panics if name is already registered.
+func Register(name string, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if _, exists := registry[name]; exists {
+		panic(fmt.Sprintf("combiner: %q is already registered", name))
+	}
+
+	registry[name] = factory
+}
+
+// Names returns the names of all of the registered Combiners, sorted.
+func Names() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// Parse creates a Combiner from a spec. A spec is the name of a registered Combiner, optionally
+// followed by "?" and "&"-separated parameters, such as "alphablend?bg=#000000". A parameter value
+// that is itself a spec with more than one parameter can be wrapped in parentheses, so that its
+// "&"s are kept with it, such as "weighted?a=(hue?origin=200&reverse=true)&b=red".
+func Parse(spec string) (Combiner, error) {
+	cmb, _, err := parse(spec)
+
+	return cmb, err
+}
+
+// IntParams gives the names of the parameters that the Combiner for spec reads as integers, such
+// as the "radius" of "localcontrast". Parameters that the Combiner only reads for some specs are
+// only included if spec is one of them.
+func IntParams(spec string) (map[string]bool, error) {
+	_, p, err := parse(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.ints, nil
+}
+
+// parse creates a Combiner from a spec, and gives the Params that its Factory read
+func parse(spec string) (Combiner, *Params, error) {
+	name, values, err := splitSpec(spec)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	registryMu.RLock()
+	factory, ok := registry[name]
+	registryMu.RUnlock()
+
+	if !ok {
+		return nil, nil, &SpecError{
+			Spec: spec,
+			Msg:  fmt.Sprintf("unknown combiner %q (expected one of %s)", name, strings.Join(Names(), ", ")),
+		}
+	}
+
+	p := &Params{spec: spec, values: values, used: map[string]bool{}, ints: map[string]bool{}}
+
+	cmb, err := factory(p)
+	if p.err != nil {
+		return nil, nil, p.err
+	}
+
+	if err != nil {
+		if _, isSpecErr := err.(*SpecError); isSpecErr {
+			return nil, nil, err
+		}
+
+		return nil, nil, &SpecError{Spec: spec, Msg: err.Error()}
+	}
+
+	if err := p.checkUnused(); err != nil {
+		return nil, nil, err
+	}
+
+	return cmb, p, nil
+}
+
+// MustParse is like Parse, but panics if the spec can't be parsed.
+func MustParse(spec string) Combiner {
+	cmb, err := Parse(spec)
+	if err != nil {
+		panic(err)
+	}
+
+	return cmb
+}
@@ -0,0 +1,168 @@
+// Package spacecurve implements combiner.Combiner by mapping each color to its position along a
+// space-filling curve through a three dimensional color space, so that similar colors end up
+// near each other regardless of which channel they differ in.
+//
+// Each axis gets the full 16 bits of precision, so every key fits in 48 bits. Colors are used
+// without alpha-premultiplication.
+package spacecurve
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/dcormier/go-pixelsort/colorspace"
+	"github.com/dcormier/go-pixelsort/combiner"
+)
+
+// bits is the number of bits of precision along each axis of the color space
+const bits = 16
+
+var (
+	// HilbertRGB keys on the position along a Hilbert curve through the sRGB cube
+	HilbertRGB = New("hilbert (rgb)", "Position along a Hilbert curve through the sRGB cube", RGB, Hilbert)
+
+	// HilbertLab keys on the position along a Hilbert curve through CIE L*a*b*
+	HilbertLab = New("hilbert (lab)", "Position along a Hilbert curve through CIE L*a*b*", Lab, Hilbert)
+
+	// MortonRGB keys on the Morton code (Z-order) of the sRGB cube
+	MortonRGB = New("morton (rgb)", "Morton code (Z-order) of the sRGB cube", RGB, Morton)
+
+	// MortonLab keys on the Morton code (Z-order) of CIE L*a*b*
+	MortonLab = New("morton (lab)", "Morton code (Z-order) of CIE L*a*b*", Lab, Morton)
+)
+
+func init() {
+	combiner.Register("hilbert", factory(HilbertRGB, HilbertLab))
+	combiner.Register("morton", factory(MortonRGB, MortonLab))
+}
+
+// factory creates a combiner.Factory that picks between the RGB and Lab versions of a curve
+func factory(rgb, lab combiner.Combiner) combiner.Factory {
+	return func(p *combiner.Params) (combiner.Combiner, error) {
+		if p.Choice("space", "rgb", "rgb", "lab") == "lab" {
+			return lab, nil
+		}
+
+		return rgb, nil
+	}
+}
+
+// Space maps a color to its coordinates in a color space, scaled to 16 bits per axis
+type Space func(c color.Color) [3]uint32
+
+// Curve maps 16-bit coordinates to a position along a space-filling curve
+type Curve func(p [3]uint32) uint64
+
+// RGB is the sRGB color cube
+func RGB(c color.Color) [3]uint32 {
+	n := colorspace.NRGBA64(c)
+
+	return [3]uint32{uint32(n.R), uint32(n.G), uint32(n.B)}
+}
+
+// Lab is CIE L*a*b*, with a* and b* clamped to [-128, 128]
+func Lab(c color.Color) [3]uint32 {
+	lab := colorspace.LabOf(c)
+
+	return [3]uint32{scale(lab.L, 0, 100), scale(lab.A, -128, 128), scale(lab.B, -128, 128)}
+}
+
+// scale maps v from [min, max] to [0, 0xffff]
+func scale(v, min, max float64) uint32 {
+	v = (v - min) / (max - min)
+
+	return uint32(math.Max(0, math.Min(1, v))*0xffff + 0.5)
+}
+
+// Hilbert gives the position of p along a Hilbert curve
+func Hilbert(p [3]uint32) uint64 {
+	return hilbert(p, bits)
+}
+
+// Morton gives the Morton code (Z-order) of p
+func Morton(p [3]uint32) uint64 {
+	return interleave(p, bits)
+}
+
+// hilbert gives the position of p along a Hilbert curve with n bits of precision per axis, using
+// John Skilling's "Programming the Hilbert curve" (https://doi.org/10.1063/1.1751381).
+func hilbert(p [3]uint32, n uint) uint64 {
+	m := uint32(1) << (n - 1)
+
+	// Inverse undo excess work
+	for q := m; q > 1; q >>= 1 {
+		mask := q - 1
+
+		for i := range p {
+			if p[i]&q != 0 {
+				p[0] ^= mask
+			} else {
+				t := (p[0] ^ p[i]) & mask
+				p[0] ^= t
+				p[i] ^= t
+			}
+		}
+	}
+
+	// Gray encode
+	for i := 1; i < len(p); i++ {
+		p[i] ^= p[i-1]
+	}
+
+	var t uint32
+	for q := m; q > 1; q >>= 1 {
+		if p[len(p)-1]&q != 0 {
+			t ^= q - 1
+		}
+	}
+
+	for i := range p {
+		p[i] ^= t
+	}
+
+	return interleave(p, n)
+}
+
+// interleave combines the low n bits of each axis of p, most significant bits first
+func interleave(p [3]uint32, n uint) uint64 {
+	var idx uint64
+
+	for b := int(n) - 1; b >= 0; b-- {
+		for _, v := range p {
+			idx = idx<<1 | uint64(v>>uint(b)&1)
+		}
+	}
+
+	return idx
+}
+
+var _ combiner.Combiner = (*spaceCurve)(nil)
+
+type spaceCurve struct {
+	name        string
+	description string
+	space       Space
+	curve       Curve
+}
+
+// New creates a combiner.Combiner with the given name and description that keys on the position
+// of each color along curve through space
+func New(name, description string, space Space, curve Curve) combiner.Combiner {
+	return &spaceCurve{name: name, description: description, space: space, curve: curve}
+}
+
+func (sc *spaceCurve) Name() string {
+	return sc.name
+}
+
+func (sc *spaceCurve) Metadata() combiner.Metadata {
+	return combiner.Metadata{
+		Description:  sc.description,
+		Max:          1<<(3*bits) - 1,
+		IgnoresAlpha: true,
+	}
+}
+
+func (sc *spaceCurve) Combine(c color.Color) uint64 {
+	return sc.curve(sc.space(c))
+}
@@ -0,0 +1,100 @@
+package spacecurve
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dcormier/go-pixelsort/combiner"
+	"github.com/dcormier/go-pixelsort/combiner/combinertest"
+)
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+
+	return v
+}
+
+func TestHilbert(t *testing.T) {
+	t.Parallel()
+
+	const n = 4
+	const side = 1 << n
+
+	points := make(map[uint64][3]uint32, side*side*side)
+
+	for x := uint32(0); x < side; x++ {
+		for y := uint32(0); y < side; y++ {
+			for z := uint32(0); z < side; z++ {
+				p := [3]uint32{x, y, z}
+				idx := hilbert(p, n)
+
+				require.True(t, idx < side*side*side, "%v is out of range", idx)
+				require.NotContains(t, points, idx, "%v is used more than once", idx)
+
+				points[idx] = p
+			}
+		}
+	}
+
+	// Each step along the curve must move to an adjacent point
+	for idx := uint64(1); idx < side*side*side; idx++ {
+		prev, cur := points[idx-1], points[idx]
+
+		dist := 0
+		for i := range cur {
+			dist += abs(int(cur[i]) - int(prev[i]))
+		}
+
+		require.Equal(t, 1, dist, "%v (%v) -> %v (%v)", idx-1, prev, idx, cur)
+	}
+}
+
+func TestMorton(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, uint64(0), Morton([3]uint32{0, 0, 0}))
+	assert.Equal(t, uint64(0x4), Morton([3]uint32{1, 0, 0}))
+	assert.Equal(t, uint64(0x2), Morton([3]uint32{0, 1, 0}))
+	assert.Equal(t, uint64(0x1), Morton([3]uint32{0, 0, 1}))
+	assert.Equal(t, uint64(0x38), Morton([3]uint32{2, 2, 2}))
+	assert.Equal(t, uint64(1)<<48-1, Morton([3]uint32{0xffff, 0xffff, 0xffff}))
+}
+
+func TestKeyRange(t *testing.T) {
+	t.Parallel()
+
+	white := color.NRGBA64{R: 0xffff, G: 0xffff, B: 0xffff, A: 0xffff}
+
+	for _, cmb := range []interface {
+		Combine(color.Color) uint64
+	}{HilbertRGB, HilbertLab, MortonRGB, MortonLab} {
+		assert.True(t, cmb.Combine(white) < 1<<48)
+	}
+
+	// Full 16-bit precision: neighboring 16-bit colors get different keys
+	a := color.NRGBA64{R: 0x8000, G: 0x8000, B: 0x8000, A: 0xffff}
+	b := color.NRGBA64{R: 0x8000, G: 0x8001, B: 0x8000, A: 0xffff}
+	assert.NotEqual(t, HilbertRGB.Combine(a), HilbertRGB.Combine(b))
+	assert.NotEqual(t, MortonRGB.Combine(a), MortonRGB.Combine(b))
+}
+
+func TestConformance(t *testing.T) {
+	t.Parallel()
+
+	for _, cmb := range []combiner.Combiner{
+		HilbertRGB,
+		HilbertLab,
+		MortonRGB,
+		MortonLab,
+	} {
+		cmb := cmb
+		t.Run(cmb.Name(), func(t *testing.T) {
+			combinertest.Run(t, cmb)
+		})
+	}
+}
@@ -0,0 +1,340 @@
+package combiner
+
+import (
+	"fmt"
+	"image/color"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dcormier/go-pixelsort/colorspace"
+)
+
+// SpecError describes a problem with a combiner spec
+type SpecError struct {
+	Spec string
+
+	// Param is the name of the parameter with the problem, if there is one
+	Param string
+
+	Msg string
+}
+
+func (e *SpecError) Error() string {
+	if e.Param == "" {
+		return fmt.Sprintf("combiner spec %q: %s", e.Spec, e.Msg)
+	}
+
+	return fmt.Sprintf("combiner spec %q: parameter %q: %s", e.Spec, e.Param, e.Msg)
+}
+
+// splitSpec splits a spec into the combiner name and its parameter values.
+func splitSpec(spec string) (name string, values map[string]string, err error) {
+	values = map[string]string{}
+
+	q := strings.IndexByte(spec, '?')
+	if q < 0 {
+		name = strings.TrimSpace(spec)
+	} else {
+		name = strings.TrimSpace(spec[:q])
+	}
+
+	if name == "" {
+		return "", nil, &SpecError{Spec: spec, Msg: "missing combiner name"}
+	}
+
+	if q < 0 {
+		return name, values, nil
+	}
+
+	for _, pair := range splitParams(spec[q+1:]) {
+		if pair == "" {
+			continue
+		}
+
+		eq := strings.IndexByte(pair, '=')
+		if eq < 0 {
+			return "", nil, &SpecError{Spec: spec, Msg: fmt.Sprintf("expected name=value, found %q", pair)}
+		}
+
+		key := strings.TrimSpace(pair[:eq])
+		if key == "" {
+			return "", nil, &SpecError{Spec: spec, Msg: fmt.Sprintf("missing parameter name in %q", pair)}
+		}
+
+		if _, dup := values[key]; dup {
+			return "", nil, &SpecError{Spec: spec, Param: key, Msg: "given more than once"}
+		}
+
+		values[key] = strings.TrimSpace(pair[eq+1:])
+	}
+
+	return name, values, nil
+}
+
+// splitParams splits s on the "&"s that aren't inside of parentheses.
+func splitParams(s string) []string {
+	var params []string
+
+	depth, start := 0, 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '(':
+			depth++
+		case ')':
+			if depth > 0 {
+				depth--
+			}
+		case '&':
+			if depth == 0 {
+				params = append(params, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+
+	return append(params, s[start:])
+}
+
+// WithParam gives spec with the named parameter set to value, replacing it if it was already
+// given. The parameters of the returned spec are in order by name. A value with "&"s in it should
+// be wrapped in parentheses, as it would be in any other spec.
+func WithParam(spec, param, value string) (string, error) {
+	name, values, err := splitSpec(spec)
+	if err != nil {
+		return "", err
+	}
+
+	values[param] = value
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	params := make([]string, len(keys))
+	for i, key := range keys {
+		params[i] = key + "=" + values[key]
+	}
+
+	return name + "?" + strings.Join(params, "&"), nil
+}
+
+// Params gives a Factory typed access to the parameters of a spec. The first problem found with
+// a parameter is remembered and reported by Parse, so a Factory can read all of its parameters
+// before checking for errors (or not check at all). Parse also reports any parameters in the
+// spec that the Factory never asked for.
+type Params struct {
+	spec   string
+	values map[string]string
+	used   map[string]bool
+	ints   map[string]bool
+	err    error
+}
+
+// Err returns the first problem found with a parameter, if any.
+func (p *Params) Err() error {
+	return p.err
+}
+
+// Errorf records a problem with the named parameter, unless a problem has already been recorded.
+func (p *Params) Errorf(name, format string, args ...interface{}) {
+	if p.err == nil {
+		p.err = &SpecError{Spec: p.spec, Param: name, Msg: fmt.Sprintf(format, args...)}
+	}
+}
+
+func (p *Params) lookup(name string) (string, bool) {
+	p.used[name] = true
+
+	v, ok := p.values[name]
+
+	return v, ok
+}
+
+// Has reports whether the named parameter was given.
+func (p *Params) Has(name string) bool {
+	_, ok := p.lookup(name)
+
+	return ok
+}
+
+// String returns the named parameter, or def if it wasn't given.
+func (p *Params) String(name, def string) string {
+	if v, ok := p.lookup(name); ok {
+		return v
+	}
+
+	return def
+}
+
+// Choice returns the named parameter, or def if it wasn't given. The value must be one of
+// choices (ignoring case).
+func (p *Params) Choice(name, def string, choices ...string) string {
+	v, ok := p.lookup(name)
+	if !ok {
+		return def
+	}
+
+	for _, choice := range choices {
+		if strings.EqualFold(v, choice) {
+			return choice
+		}
+	}
+
+	p.Errorf(name, "%q is not one of %s", v, strings.Join(choices, ", "))
+
+	return def
+}
+
+// Float returns the named parameter, or def if it wasn't given.
+func (p *Params) Float(name string, def float64) float64 {
+	v, ok := p.lookup(name)
+	if !ok {
+		return def
+	}
+
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		p.Errorf(name, "%q is not a number", v)
+		return def
+	}
+
+	return f
+}
+
+// FloatIn is like Float, but the value must also be in [min, max].
+func (p *Params) FloatIn(name string, def, min, max float64) float64 {
+	f := p.Float(name, def)
+	if f < min || f > max {
+		p.Errorf(name, "%v is out of range [%v, %v]", f, min, max)
+		return def
+	}
+
+	return f
+}
+
+// Int returns the named parameter, or def if it wasn't given.
+func (p *Params) Int(name string, def int) int {
+	p.ints[name] = true
+
+	v, ok := p.lookup(name)
+	if !ok {
+		return def
+	}
+
+	i, err := strconv.Atoi(v)
+	if err != nil {
+		p.Errorf(name, "%q is not an integer", v)
+		return def
+	}
+
+	return i
+}
+
+// IntIn is like Int, but the value must also be in [min, max].
+func (p *Params) IntIn(name string, def, min, max int) int {
+	i := p.Int(name, def)
+	if i < min || i > max {
+		p.Errorf(name, "%v is out of range [%v, %v]", i, min, max)
+		return def
+	}
+
+	return i
+}
+
+// Bool returns the named parameter, or def if it wasn't given.
+func (p *Params) Bool(name string, def bool) bool {
+	v, ok := p.lookup(name)
+	if !ok {
+		return def
+	}
+
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		p.Errorf(name, "%q is not true or false", v)
+		return def
+	}
+
+	return b
+}
+
+// Color returns the named parameter as a hex color (such as "#0050ff"), or def if it wasn't
+// given. If def is nil, the parameter is required.
+func (p *Params) Color(name string, def color.Color) color.Color {
+	v, ok := p.lookup(name)
+	if !ok {
+		if def == nil {
+			p.Errorf(name, "required, but not given")
+		}
+
+		return def
+	}
+
+	c, err := colorspace.ParseHex(v)
+	if err != nil {
+		p.Errorf(name, "%v", err)
+		return def
+	}
+
+	return c
+}
+
+// Combiner returns the named parameter parsed as a spec, or def if it wasn't given. If def is
+// nil, the parameter is required. The value may be wrapped in parentheses.
+func (p *Params) Combiner(name string, def Combiner) Combiner {
+	v, ok := p.lookup(name)
+	if !ok {
+		if def == nil {
+			p.Errorf(name, "required, but not given")
+		}
+
+		return def
+	}
+
+	if strings.HasPrefix(v, "(") && strings.HasSuffix(v, ")") {
+		v = v[1 : len(v)-1]
+	}
+
+	cmb, err := Parse(v)
+	if err != nil {
+		p.Errorf(name, "%v", err)
+		return def
+	}
+
+	return cmb
+}
+
+// checkUnused returns an error if any of the parameters weren't asked for.
+func (p *Params) checkUnused() error {
+	var unknown []string
+	for name := range p.values {
+		if !p.used[name] {
+			unknown = append(unknown, name)
+		}
+	}
+
+	if len(unknown) == 0 {
+		return nil
+	}
+
+	sort.Strings(unknown)
+
+	known := make([]string, 0, len(p.used))
+	for name := range p.used {
+		known = append(known, name)
+	}
+
+	sort.Strings(known)
+
+	msg := fmt.Sprintf("unknown parameter(s) %s", strings.Join(unknown, ", "))
+	if len(known) == 0 {
+		msg += " (this combiner doesn't take any parameters)"
+	} else {
+		msg += fmt.Sprintf(" (expected %s)", strings.Join(known, ", "))
+	}
+
+	return &SpecError{Spec: p.spec, Msg: msg}
+}
@@ -0,0 +1,137 @@
+package combiner_test
+
+import (
+	"image/color"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dcormier/go-pixelsort/combiner"
+	_ "github.com/dcormier/go-pixelsort/combiner/all"
+	"github.com/dcormier/go-pixelsort/combiner/alphablend"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	for spec, expectedName := range map[string]string{
+		"basic":                                 "basic",
+		"alphablend":                            "alpha blend",
+		"alphablend?bg=#000000":                 "alpha blend (over #000000)",
+		"alphablend?bg=#fff":                    "alpha blend",
+		" alphablend ? bg = #000 ":              "alpha blend (over #000000)",
+		"hue?origin=200":                        "hue (from 200°)",
+		"hilbert?space=LAB":                     "hilbert (lab)",
+		"distance?ref=#0050ff":                  "distance (ciede2000 from #0050ff)",
+		"distance?ref=#0050ff&metric=rgb":       "distance (rgb from #0050ff)",
+		"expr?e=max(r,g,b)-min(r,g,b)":          "expression (max(r,g,b)-min(r,g,b))",
+		"expr?e=(r + g) * b":                    "expression ((r + g) * b)",
+		"perceivedoption2noalpha?":              "perceived (option 2, no alpha)",
+		"distance?metric=cie76&&ref=#0050ff&":   "distance (cie76 from #0050ff)",
+		"minchannel":                            "min channel",
+		"cr":                                    "Cr",
+		"morton?space=rgb":                      "morton (rgb)",
+		"standardobjective":                     "standard objective",
+		"perceivedoption1":                      "perceived (option 1)",
+		"distance?ref=#0050ff&metric=CIEDE2000": "distance (ciede2000 from #0050ff)",
+	} {
+		cmb, err := combiner.Parse(spec)
+		require.NoError(t, err, spec)
+		assert.Equal(t, expectedName, cmb.Name(), spec)
+	}
+
+	assert.Equal(t, alphablend.Combiner, combiner.MustParse("alphablend"))
+}
+
+func TestParseErrors(t *testing.T) {
+	t.Parallel()
+
+	for spec, expected := range map[string]string{
+		"":                         `combiner spec "": missing combiner name`,
+		"?bg=#000":                 `combiner spec "?bg=#000": missing combiner name`,
+		"alphablend?bg":            `combiner spec "alphablend?bg": expected name=value, found "bg"`,
+		"alphablend?=#000":         `combiner spec "alphablend?=#000": missing parameter name in "=#000"`,
+		"alphablend?bg=#000&bg=#f": `combiner spec "alphablend?bg=#000&bg=#f": parameter "bg": given more than once`,
+		"alphablend?bg=black":      `combiner spec "alphablend?bg=black": parameter "bg": invalid hex color "black": expected 3, 4, 6 or 8 hex digits`,
+		"alphablend?fg=#000":       `combiner spec "alphablend?fg=#000": unknown parameter(s) fg (expected bg)`,
+		"basic?levels=4":           `combiner spec "basic?levels=4": unknown parameter(s) levels (this combiner doesn't take any parameters)`,
+		"hue?origin=abc":           `combiner spec "hue?origin=abc": parameter "origin": "abc" is not a number`,
+		"hue?origin=400":           `combiner spec "hue?origin=400": parameter "origin": 400 is out of range [-360, 360]`,
+		"hilbert?space=hsv":        `combiner spec "hilbert?space=hsv": parameter "space": "hsv" is not one of rgb, lab`,
+		"distance":                 `combiner spec "distance": parameter "ref": required, but not given`,
+		"distance?ref=#000&metric=manhattan": `combiner spec "distance?ref=#000&metric=manhattan": parameter "metric": ` +
+			`unknown distance metric "manhattan" (expected one of rgb, cie76, ciede2000)`,
+		"expr":         `combiner spec "expr": parameter "e": required, but not given`,
+		"expr?e=r +":   `combiner spec "expr?e=r +": parameter "e": column 4: expected a number, variable, function or "(", found end of expression`,
+		"unknownthing": `combiner spec "unknownthing": unknown combiner "unknownthing" (expected one of `,
+	} {
+		_, err := combiner.Parse(spec)
+		require.Error(t, err, spec)
+		assert.IsType(t, &combiner.SpecError{}, err, spec)
+		assert.Contains(t, err.Error(), expected, spec)
+	}
+
+	assert.Panics(t, func() { combiner.MustParse("nope") })
+	assert.Panics(t, func() { combiner.Register("basic", combiner.Static(alphablend.Combiner)) })
+}
+
+func TestWithParam(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range []struct {
+		spec, param, value, expected string
+	}{
+		{"hue", "origin", "200", "hue?origin=200"},
+		{"hue?origin=10", "origin", "20", "hue?origin=20"},
+		{" noise ? strength = 0.1 & scale=8", "seed", "3", "noise?scale=8&seed=3&strength=0.1"},
+		{"weighted?a=(noise?scale=8&strength=1)&b=red", "w", "0.25",
+			"weighted?a=(noise?scale=8&strength=1)&b=red&w=0.25"},
+	} {
+		spec, err := combiner.WithParam(test.spec, test.param, test.value)
+		require.NoError(t, err, test.spec)
+		assert.Equal(t, test.expected, spec, test.spec)
+
+		_, err = combiner.Parse(spec)
+		assert.NoError(t, err, spec)
+	}
+
+	_, err := combiner.WithParam("?origin=1", "origin", "2")
+	assert.EqualError(t, err, `combiner spec "?origin=1": missing combiner name`)
+}
+
+func TestNames(t *testing.T) {
+	t.Parallel()
+
+	names := combiner.Names()
+	assert.Contains(t, names, "alphablend")
+	assert.Contains(t, names, "distance")
+	assert.Contains(t, names, "expr")
+	assert.True(t, sort.StringsAreSorted(names))
+}
+
+func TestParseCombines(t *testing.T) {
+	t.Parallel()
+
+	black := combiner.MustParse("alphablend?bg=#000000")
+	white := combiner.MustParse("alphablend")
+
+	// Over black, a transparent pixel is dark; over white, it's bright
+	assert.True(t, black.Combine(color.Transparent) < white.Combine(color.Transparent))
+}
+
+func TestIntParams(t *testing.T) {
+	t.Parallel()
+
+	ints, err := combiner.IntParams("localcontrast")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{"radius": true}, ints)
+
+	ints, err = combiner.IntParams("hue?origin=200")
+	require.NoError(t, err)
+	assert.Empty(t, ints)
+
+	_, err = combiner.IntParams("localcontrast?radius=0.5")
+	assert.EqualError(t, err, `combiner spec "localcontrast?radius=0.5": parameter "radius": "0.5" is not an integer`)
+}
@@ -11,6 +11,10 @@ import (
 // Combiner is an instance of this Combiner
 var Combiner = New()
 
+func init() {
+	combiner.Register("standardobjective", combiner.Static(Combiner))
+}
+
 var _ combiner.Combiner = (*standardObjective)(nil)
 
 type standardObjective struct{}
@@ -24,10 +28,17 @@ func (*standardObjective) Name() string {
 	return "standard objective"
 }
 
+func (*standardObjective) Metadata() combiner.Metadata {
+	return combiner.Metadata{
+		Description: "Objective brightness, using BT.709 luminance weights on a white background",
+		Max:         combiner.AlphaBlendMax,
+	}
+}
+
 func (*standardObjective) Combine(c color.Color) uint64 {
 	r, g, b, a := c.RGBA()
 
-	return uint64(combiner.AlphaBlend(r, a, combiner.CMax)*0.2126 +
-		combiner.AlphaBlend(g, a, combiner.CMax)*0.7152 +
-		combiner.AlphaBlend(b, a, combiner.CMax)*0.0722)
+	return combiner.ClampBlended(combiner.AlphaBlend(r, a, combiner.CMax)*0.2126+
+		combiner.AlphaBlend(g, a, combiner.CMax)*0.7152+
+		combiner.AlphaBlend(b, a, combiner.CMax)*0.0722, combiner.AlphaBlendMax)
 }
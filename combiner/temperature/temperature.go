@@ -0,0 +1,101 @@
+// Package temperature implements combiner.Combiner using an estimate of each color's correlated
+// color temperature (CCT), in kelvin. Sorting in ascending order goes from warm (reddish) to cool
+// (bluish) colors.
+//
+// The estimate uses McCamy's approximation (https://doi.org/10.1002/col.5080170211) on the color's
+// CIE 1931 xy chromaticity. Grays have the chromaticity of sRGB's white point, so they come out at
+// about 6500K. Dark colors don't have enough light to have a reliable chromaticity, so they are
+// pulled toward that same neutral temperature the darker they are. Colors that are far from the
+// Planckian locus still get a temperature, but it's only a rough guide.
+package temperature
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+
+	"github.com/dcormier/go-pixelsort/colorspace"
+	"github.com/dcormier/go-pixelsort/combiner"
+)
+
+const (
+	// MinKelvin and MaxKelvin are the limits of the temperatures given
+	MinKelvin = 1000
+	MaxKelvin = 25000
+
+	// NeutralKelvin is the temperature of sRGB's white point (D65)
+	NeutralKelvin = 6504
+
+	// DefaultDark is the default luminance (Y, in [0, 1]) below which colors are treated as dark
+	DefaultDark = 0.02
+)
+
+// Combiner is an instance of this Combiner
+var Combiner = New(DefaultDark)
+
+func init() {
+	combiner.Register("temperature", func(p *combiner.Params) (combiner.Combiner, error) {
+		return New(p.FloatIn("dark", DefaultDark, 0, 1)), nil
+	})
+}
+
+var _ combiner.Combiner = (*temperature)(nil)
+
+type temperature struct {
+	dark float64
+}
+
+// New creates a combiner.Combiner that uses color temperature. Colors with a luminance (Y, in
+// [0, 1]) below dark are pulled toward a neutral temperature.
+func New(dark float64) combiner.Combiner {
+	return &temperature{dark: dark}
+}
+
+func (t *temperature) Name() string {
+	if t.dark == DefaultDark {
+		return "color temperature"
+	}
+
+	return fmt.Sprintf("color temperature (dark below %v)", t.dark)
+}
+
+func (t *temperature) Metadata() combiner.Metadata {
+	return combiner.Metadata{
+		Description:  "Correlated color temperature, from warm to cool",
+		Min:          MinKelvin,
+		Max:          MaxKelvin,
+		IgnoresAlpha: true,
+	}
+}
+
+func (t *temperature) Combine(c color.Color) uint64 {
+	return uint64(Kelvin(colorspace.XYZOf(c), t.dark) + 0.5)
+}
+
+// Kelvin estimates the correlated color temperature of c, in [MinKelvin, MaxKelvin]. Colors with
+// a luminance below dark are pulled toward NeutralKelvin.
+func Kelvin(c colorspace.XYZ, dark float64) float64 {
+	if c.Y <= 0 {
+		return NeutralKelvin
+	}
+
+	x, y := c.Chromaticity()
+
+	var cct float64
+	if y <= 0.1858 {
+		// McCamy's approximation falls apart below its epicenter, where there are only blues and
+		// purples, which are all as cool as it gets
+		cct = MaxKelvin
+	} else {
+		n := (x - 0.3320) / (0.1858 - y)
+		cct = 449*n*n*n + 3525*n*n + 6823.3*n + 5520.33
+		cct = math.Max(MinKelvin, math.Min(MaxKelvin, cct))
+	}
+
+	if c.Y < dark {
+		weight := c.Y / dark
+		cct = NeutralKelvin + (cct-NeutralKelvin)*weight
+	}
+
+	return cct
+}
@@ -0,0 +1,69 @@
+package temperature
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dcormier/go-pixelsort/colorspace"
+	"github.com/dcormier/go-pixelsort/combiner/combinertest"
+)
+
+func TestKelvin(t *testing.T) {
+	t.Parallel()
+
+	// Chromaticities of standard illuminants, with their nominal temperatures
+	for _, test := range []struct {
+		name     string
+		x, y     float64
+		expected float64
+	}{
+		{"A", 0.44757, 0.40745, 2856},
+		{"D50", 0.34567, 0.35850, 5003},
+		{"D65", 0.31271, 0.32902, 6504},
+		{"D75", 0.29902, 0.31485, 7504},
+	} {
+		c := colorspace.XYZ{X: test.x / test.y, Y: 1, Z: (1 - test.x - test.y) / test.y}
+		assert.InDelta(t, test.expected, Kelvin(c, DefaultDark), 20, test.name)
+	}
+}
+
+func TestCombine(t *testing.T) {
+	t.Parallel()
+
+	gray := color.NRGBA{R: 0x80, G: 0x80, B: 0x80, A: 0xff}
+	assert.InDelta(t, NeutralKelvin, Combiner.Combine(gray), 10)
+	assert.InDelta(t, NeutralKelvin, Combiner.Combine(color.Black), 1)
+
+	// Warm to cool
+	ordered := []color.Color{
+		color.NRGBA{R: 0xff, G: 0x60, B: 0x00, A: 0xff},
+		color.NRGBA{R: 0xff, G: 0xb0, B: 0x60, A: 0xff},
+		color.NRGBA{R: 0xff, G: 0xf0, B: 0xe0, A: 0xff},
+		color.NRGBA{R: 0xe0, G: 0xf0, B: 0xff, A: 0xff},
+		color.NRGBA{R: 0xa0, G: 0xc0, B: 0xff, A: 0xff},
+	}
+
+	for i := 1; i < len(ordered); i++ {
+		assert.True(t, Combiner.Combine(ordered[i-1]) < Combiner.Combine(ordered[i]),
+			"%v should be warmer than %v", ordered[i-1], ordered[i])
+	}
+
+	assert.Equal(t, uint64(MaxKelvin), Combiner.Combine(color.NRGBA{B: 0xff, A: 0xff}))
+
+	// A very dark orange is nearly neutral
+	darkOrange := color.NRGBA{R: 0x08, G: 0x03, A: 0xff}
+	assert.InDelta(t, NeutralKelvin, Combiner.Combine(darkOrange), 1000)
+
+	for _, c := range ordered {
+		v := Combiner.Combine(c)
+		assert.True(t, MinKelvin <= v && v <= MaxKelvin)
+	}
+}
+
+func TestConformance(t *testing.T) {
+	t.Parallel()
+
+	combinertest.Run(t, Combiner)
+}
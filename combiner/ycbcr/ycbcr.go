@@ -0,0 +1,59 @@
+// Package ycbcr implements combiner.Combiner by keying on a single component of a color's
+// full range Y'CbCr representation.
+// Sources that are already Y'CbCr (such as decoded JPEGs) use their components directly.
+package ycbcr
+
+import (
+	"image/color"
+
+	"github.com/dcormier/go-pixelsort/colorspace"
+	"github.com/dcormier/go-pixelsort/combiner"
+)
+
+var (
+	// Y keys on luma
+	Y = New("Y", "Luma", func(c colorspace.YCbCr) float64 { return c.Y })
+
+	// Cb keys on the blue-difference chroma
+	Cb = New("Cb", "Blue-difference chroma", func(c colorspace.YCbCr) float64 { return c.Cb })
+
+	// Cr keys on the red-difference chroma
+	Cr = New("Cr", "Red-difference chroma", func(c colorspace.YCbCr) float64 { return c.Cr })
+)
+
+func init() {
+	combiner.Register("y", combiner.Static(Y))
+	combiner.Register("cb", combiner.Static(Cb))
+	combiner.Register("cr", combiner.Static(Cr))
+}
+
+var _ combiner.Combiner = (*component)(nil)
+
+type component struct {
+	name        string
+	description string
+	key         func(colorspace.YCbCr) float64
+}
+
+// New creates a combiner.Combiner with the given name and description that uses key to pick a
+// component value in [0, 1]
+func New(name, description string, key func(colorspace.YCbCr) float64) combiner.Combiner {
+	return &component{name: name, description: description, key: key}
+}
+
+func (cmp *component) Name() string {
+	return cmp.name
+}
+
+func (cmp *component) Metadata() combiner.Metadata {
+	return combiner.Metadata{
+		Description:  cmp.description + " of full range Y'CbCr",
+		Max:          0xffff,
+		IgnoresAlpha: true,
+	}
+}
+
+func (cmp *component) Combine(c color.Color) uint64 {
+	// Scale up to 16 bits so 16-bit sources keep their precision
+	return uint64(cmp.key(colorspace.YCbCrOf(c))*0xffff + 0.5)
+}
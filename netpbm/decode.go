@@ -0,0 +1,204 @@
+package netpbm
+
+import (
+	"fmt"
+	"image"
+	"io"
+	"strconv"
+)
+
+// readImage reads the samples of an image with the given header
+func (d *decoder) readImage(h header) (image.Image, error) {
+	bounds := image.Rect(0, 0, h.width, h.height)
+
+	if h.format == PBM {
+		img := image.NewGray(bounds)
+
+		return img, d.readBits(h, img)
+	}
+
+	// Samples are scaled to 8 bits, or to 16 if there are more than 8 bits of them
+	wide := h.maxval > 0xff
+	full := 0xff
+	if wide {
+		full = 0xffff
+	}
+
+	row := make([]int, h.width*h.depth)
+
+	// set stores the scaled samples of a row of pixels
+	var set func(y int, row []int)
+
+	var img image.Image
+
+	switch {
+	case h.depth == 1 && wide:
+		gray := image.NewGray16(bounds)
+		set = func(y int, row []int) {
+			pix := gray.Pix[y*gray.Stride:]
+			for x, s := range row {
+				pix[2*x], pix[2*x+1] = uint8(s>>8), uint8(s)
+			}
+		}
+
+		img = gray
+
+	case h.depth == 1:
+		gray := image.NewGray(bounds)
+		set = func(y int, row []int) {
+			pix := gray.Pix[y*gray.Stride:]
+			for x, s := range row {
+				pix[x] = uint8(s)
+			}
+		}
+
+		img = gray
+
+	default:
+		var (
+			pix    []uint8
+			stride int
+		)
+
+		if wide {
+			rgba := image.NewNRGBA64(bounds)
+			pix, stride, img = rgba.Pix, rgba.Stride, rgba
+		} else {
+			rgba := image.NewNRGBA(bounds)
+			pix, stride, img = rgba.Pix, rgba.Stride, rgba
+		}
+
+		set = func(y int, row []int) {
+			p := pix[y*stride:]
+			for x := 0; x < h.width; x++ {
+				c := pixel(row[x*h.depth:(x+1)*h.depth], full)
+
+				for i, s := range c {
+					if wide {
+						p[8*x+2*i], p[8*x+2*i+1] = uint8(s>>8), uint8(s)
+					} else {
+						p[4*x+i] = uint8(s)
+					}
+				}
+			}
+		}
+	}
+
+	for y := 0; y < h.height; y++ {
+		if err := d.readRow(h, row); err != nil {
+			return nil, err
+		}
+
+		for i, s := range row {
+			row[i] = (s*full + h.maxval/2) / h.maxval
+		}
+
+		set(y, row)
+	}
+
+	return img, nil
+}
+
+// pixel gives the red, green, blue and alpha of a pixel from its samples
+func pixel(samples []int, full int) [4]int {
+	switch len(samples) {
+	case 2:
+		return [4]int{samples[0], samples[0], samples[0], samples[1]}
+	case 3:
+		return [4]int{samples[0], samples[1], samples[2], full}
+	}
+
+	return [4]int{samples[0], samples[1], samples[2], samples[3]}
+}
+
+// readRow reads the samples of a row of pixels, as they are
+func (d *decoder) readRow(h header, row []int) error {
+	if h.plain {
+		for i := range row {
+			token, err := d.token()
+			if err != nil {
+				return err
+			}
+
+			if row[i], err = strconv.Atoi(token); err != nil || row[i] < 0 {
+				return fmt.Errorf("netpbm: expected a sample, found %.20q", token)
+			}
+
+			if row[i] > h.maxval {
+				return fmt.Errorf("netpbm: a sample is %d, but the maxval is %d", row[i], h.maxval)
+			}
+		}
+
+		return nil
+	}
+
+	size := 1
+	if h.maxval > 0xff {
+		size = 2
+	}
+
+	if cap(d.buf) < len(row)*size {
+		d.buf = make([]byte, len(row)*size)
+	}
+
+	buf := d.buf[:len(row)*size]
+	if _, err := io.ReadFull(d.r, buf); err != nil {
+		return err
+	}
+
+	for i := range row {
+		if size == 1 {
+			row[i] = int(buf[i])
+		} else {
+			row[i] = int(buf[2*i])<<8 | int(buf[2*i+1])
+		}
+
+		if row[i] > h.maxval {
+			return fmt.Errorf("netpbm: a sample is %d, but the maxval is %d", row[i], h.maxval)
+		}
+	}
+
+	return nil
+}
+
+// readBits reads the pixels of a PBM image into img, where 1 is black and 0 is white
+func (d *decoder) readBits(h header, img *image.Gray) error {
+	// Each row of a raw image starts on a new byte
+	buf := make([]byte, (h.width+7)/8)
+
+	for y := 0; y < h.height; y++ {
+		pix := img.Pix[y*img.Stride:]
+
+		if h.plain {
+			for x := 0; x < h.width; x++ {
+				// Pixels don't have to be separated by whitespace
+				if err := d.skipSpace(); err != nil {
+					return err
+				}
+
+				c, err := d.r.ReadByte()
+				if err != nil {
+					return err
+				}
+
+				if c != '0' && c != '1' {
+					return fmt.Errorf("netpbm: expected a 0 or a 1, found %q", c)
+				}
+
+				pix[x] = 0xff * (1 - (c - '0'))
+			}
+
+			continue
+		}
+
+		if _, err := io.ReadFull(d.r, buf); err != nil {
+			return err
+		}
+
+		for x := 0; x < h.width; x++ {
+			pix[x] = 0xff * (1 - (buf[x/8]>>uint(7-x%8))&1)
+		}
+	}
+
+	return nil
+}
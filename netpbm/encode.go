@@ -0,0 +1,193 @@
+package netpbm
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+)
+
+// Options are the options for Encode
+type Options struct {
+	// Format is the format to write. If it's zero, it's PGM for grayscale images, PPM for other
+	// opaque images, and PAM for the rest.
+	Format Format
+}
+
+// is16Bit is whether img has more than 8 bits of each channel, so it has to be written with a
+// maxval of 65535 to keep them
+func is16Bit(img image.Image) bool {
+	switch img.ColorModel() {
+	case color.Gray16Model, color.RGBA64Model, color.NRGBA64Model:
+		return true
+	}
+
+	return false
+}
+
+func isGray(img image.Image) bool {
+	return img.ColorModel() == color.GrayModel || img.ColorModel() == color.Gray16Model
+}
+
+func isOpaque(img image.Image) bool {
+	o, ok := img.(interface {
+		Opaque() bool
+	})
+
+	return ok && o.Opaque()
+}
+
+// Encode writes img to w in the raw variant of a Netpbm format. PAM images keep alpha; the other
+// formats don't have it, so translucent colors are written as if they were over black. PBM images
+// are black wherever the gray of img is under half. Images with more than 8 bits of each channel
+// are written with a maxval of 65535, and others with one of 255.
+func Encode(w io.Writer, img image.Image, o *Options) error {
+	bounds := img.Bounds()
+	if bounds.Empty() {
+		return fmt.Errorf("netpbm: can't write an image that's %dx%d", bounds.Dx(), bounds.Dy())
+	}
+
+	format := PAM
+	switch {
+	case o != nil && o.Format != 0:
+		format = o.Format
+	case isGray(img):
+		format = PGM
+	case isOpaque(img):
+		format = PPM
+	}
+
+	bw := bufio.NewWriter(w)
+
+	var err error
+	if format == PBM {
+		err = writeBits(bw, img)
+	} else {
+		err = writeSamples(bw, img, format)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return bw.Flush()
+}
+
+// writeBits writes img as a PBM image
+func writeBits(w io.Writer, img image.Image) error {
+	bounds := img.Bounds()
+
+	if _, err := fmt.Fprintf(w, "P4\n%d %d\n", bounds.Dx(), bounds.Dy()); err != nil {
+		return err
+	}
+
+	row := make([]byte, (bounds.Dx()+7)/8)
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for i := range row {
+			row[i] = 0
+		}
+
+		for x := 0; x < bounds.Dx(); x++ {
+			if color.GrayModel.Convert(img.At(bounds.Min.X+x, y)).(color.Gray).Y < 0x80 {
+				row[x/8] |= 0x80 >> uint(x%8)
+			}
+		}
+
+		if _, err := w.Write(row); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeSamples writes img as a PGM, PPM or PAM image
+func writeSamples(w io.Writer, img image.Image, format Format) error {
+	bounds := img.Bounds()
+
+	maxval, size := 0xff, 1
+	if is16Bit(img) {
+		maxval, size = 0xffff, 2
+	}
+
+	// samples gives the 16-bit samples of a color
+	var (
+		samples func(c color.Color) []uint32
+		err     error
+	)
+
+	switch {
+	case format == PGM:
+		_, err = fmt.Fprintf(w, "P5\n%d %d\n%d\n", bounds.Dx(), bounds.Dy(), maxval)
+		samples = graySamples
+
+	case format == PPM:
+		_, err = fmt.Fprintf(w, "P6\n%d %d\n%d\n", bounds.Dx(), bounds.Dy(), maxval)
+		samples = rgbSamples
+
+	case format != PAM:
+		return fmt.Errorf("netpbm: unknown format %v", format)
+
+	case isGray(img):
+		err = writePAMHeader(w, bounds, 1, maxval, "GRAYSCALE")
+		samples = graySamples
+
+	case isOpaque(img):
+		err = writePAMHeader(w, bounds, 3, maxval, "RGB")
+		samples = rgbSamples
+
+	default:
+		err = writePAMHeader(w, bounds, 4, maxval, "RGB_ALPHA")
+		samples = func(c color.Color) []uint32 {
+			n := color.NRGBA64Model.Convert(c).(color.NRGBA64)
+
+			return []uint32{uint32(n.R), uint32(n.G), uint32(n.B), uint32(n.A)}
+		}
+	}
+
+	if err != nil {
+		return err
+	}
+
+	var row []byte
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		row = row[:0]
+
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			for _, s := range samples(img.At(x, y)) {
+				if size == 2 {
+					row = append(row, uint8(s>>8), uint8(s))
+				} else {
+					row = append(row, uint8(s>>8))
+				}
+			}
+		}
+
+		if _, err := w.Write(row); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writePAMHeader(w io.Writer, bounds image.Rectangle, depth, maxval int, tupleType string) error {
+	_, err := fmt.Fprintf(w, "P7\nWIDTH %d\nHEIGHT %d\nDEPTH %d\nMAXVAL %d\nTUPLTYPE %s\nENDHDR\n",
+		bounds.Dx(), bounds.Dy(), depth, maxval, tupleType)
+
+	return err
+}
+
+func graySamples(c color.Color) []uint32 {
+	return []uint32{uint32(color.Gray16Model.Convert(c).(color.Gray16).Y)}
+}
+
+// rgbSamples gives the red, green and blue of c, as they'd be over black
+func rgbSamples(c color.Color) []uint32 {
+	r, g, b, _ := c.RGBA()
+
+	return []uint32{r, g, b}
+}
@@ -0,0 +1,357 @@
+// Package netpbm reads and writes the Netpbm family of images: PBM (bitmaps), PGM (grayscale),
+// PPM (color) and PAM (any of those, with or without alpha). They're the uncompressed formats that
+// the netpbm tools, ImageMagick and ffmpeg (with "-f image2pipe -c:v pam") can pipe in and out.
+//
+// Both the plain (text) and raw variants are read, with any maxval up to 65535; images with a
+// maxval over 255 are read as 16-bit images. Only the raw variants are written. Importing this
+// package also registers the formats with the image package, as "pbm", "pgm", "ppm" and "pam".
+package netpbm
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"strconv"
+)
+
+// maxToken is the longest word of a header that's read
+const maxToken = 256
+
+// maxSamples is the most samples that an image can have, so that a bad header can't ask for more
+// memory than there is
+const maxSamples = 1 << 30
+
+func init() {
+	for n, format := range []Format{PBM, PGM, PPM, PBM, PGM, PPM, PAM} {
+		image.RegisterFormat(format.String(), fmt.Sprintf("P%d", n+1), Decode, DecodeConfig)
+	}
+}
+
+// Format is one of the formats of the Netpbm family
+type Format int
+
+const (
+	// PBM images are black and white
+	PBM Format = iota + 1
+
+	// PGM images are grayscale
+	PGM
+
+	// PPM images are RGB
+	PPM
+
+	// PAM images can be black and white, grayscale or RGB, with or without alpha
+	PAM
+)
+
+func (f Format) String() string {
+	switch f {
+	case PBM:
+		return "pbm"
+	case PGM:
+		return "pgm"
+	case PPM:
+		return "ppm"
+	case PAM:
+		return "pam"
+	}
+
+	return fmt.Sprintf("Format(%d)", int(f))
+}
+
+// tupleTypes are the PAM tuple types that can be read, and the depth of each
+var tupleTypes = map[string]int{
+	"BLACKANDWHITE":       1,
+	"GRAYSCALE":           1,
+	"RGB":                 3,
+	"BLACKANDWHITE_ALPHA": 2,
+	"GRAYSCALE_ALPHA":     2,
+	"RGB_ALPHA":           4,
+}
+
+// header describes an image
+type header struct {
+	format Format
+
+	// plain is whether the samples are written out as text
+	plain bool
+
+	width, height int
+
+	// depth is the number of samples in each pixel: 1 (gray), 2 (gray and alpha), 3 (RGB) or 4
+	// (RGB and alpha)
+	depth int
+
+	// maxval is the value of a sample that's at full intensity
+	maxval int
+}
+
+// config gives the color model and size of images with the header
+func (h header) config() image.Config {
+	var model color.Model
+
+	switch {
+	case h.depth == 1 && h.maxval > 0xff:
+		model = color.Gray16Model
+	case h.depth == 1:
+		model = color.GrayModel
+	case h.maxval > 0xff:
+		model = color.NRGBA64Model
+	default:
+		model = color.NRGBAModel
+	}
+
+	return image.Config{ColorModel: model, Width: h.width, Height: h.height}
+}
+
+// decoder reads the header and samples of an image
+type decoder struct {
+	r *bufio.Reader
+
+	// buf is reused for the samples of each row
+	buf []byte
+}
+
+func newDecoder(r io.Reader) *decoder {
+	br, ok := r.(*bufio.Reader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+
+	return &decoder{r: br}
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\v' || c == '\f' || c == '\r'
+}
+
+// skipSpace skips whitespace and comments, which run from "#" to the end of the line
+func (d *decoder) skipSpace() error {
+	for {
+		c, err := d.r.ReadByte()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case c == '#':
+			for c != '\n' {
+				if c, err = d.r.ReadByte(); err != nil {
+					return err
+				}
+			}
+
+		case !isSpace(c):
+			return d.r.UnreadByte()
+		}
+	}
+}
+
+// token reads the next word of the header, leaving whatever follows it to be read
+func (d *decoder) token() (string, error) {
+	if err := d.skipSpace(); err != nil {
+		return "", err
+	}
+
+	var token []byte
+	for {
+		c, err := d.r.ReadByte()
+		if err == io.EOF && len(token) > 0 {
+			return string(token), nil
+		}
+
+		if err != nil {
+			return "", err
+		}
+
+		if isSpace(c) || c == '#' {
+			return string(token), d.r.UnreadByte()
+		}
+
+		if token = append(token, c); len(token) > maxToken {
+			return "", fmt.Errorf("netpbm: bad header: a word is longer than %d bytes", maxToken)
+		}
+	}
+}
+
+// number reads a number from the header
+func (d *decoder) number(name string) (int, error) {
+	token, err := d.token()
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := strconv.Atoi(token)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("netpbm: bad header: expected the %s, found %.20q", name, token)
+	}
+
+	return n, nil
+}
+
+// readHeader reads the header of an image, up to its first sample
+func (d *decoder) readHeader() (h header, err error) {
+	defer func() {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+	}()
+
+	magic := make([]byte, 2)
+	if _, err := io.ReadFull(d.r, magic); err != nil {
+		return header{}, err
+	}
+
+	if magic[0] != 'P' || magic[1] < '1' || magic[1] > '7' {
+		return header{}, fmt.Errorf("netpbm: not a Netpbm image")
+	}
+
+	n := int(magic[1] - '0')
+	if n == 7 {
+		h, err = d.readPAMHeader()
+	} else {
+		h, err = d.readPNMHeader(n)
+	}
+
+	if err != nil {
+		return header{}, err
+	}
+
+	if h.width <= 0 || h.height <= 0 {
+		return header{}, fmt.Errorf("netpbm: bad header: the size is %dx%d", h.width, h.height)
+	}
+
+	if h.maxval < 1 || h.maxval > 0xffff {
+		return header{}, fmt.Errorf("netpbm: bad header: the maxval is %d (it must be from 1 to 65535)", h.maxval)
+	}
+
+	if uint64(h.width)*uint64(h.height)*uint64(h.depth) > maxSamples {
+		return header{}, fmt.Errorf("netpbm: the image is too big (%dx%d)", h.width, h.height)
+	}
+
+	return h, nil
+}
+
+// readPNMHeader reads the rest of the header of a PBM, PGM or PPM image, where n is the number of
+// its magic (1 to 6)
+func (d *decoder) readPNMHeader(n int) (header, error) {
+	h := header{
+		format: []Format{PBM, PGM, PPM}[(n-1)%3],
+		plain:  n <= 3,
+		depth:  1,
+		maxval: 1,
+	}
+
+	if h.format == PPM {
+		h.depth = 3
+	}
+
+	var err error
+	if h.width, err = d.number("width"); err != nil {
+		return header{}, err
+	}
+
+	if h.height, err = d.number("height"); err != nil {
+		return header{}, err
+	}
+
+	if h.format != PBM {
+		if h.maxval, err = d.number("maxval"); err != nil {
+			return header{}, err
+		}
+	}
+
+	if !h.plain {
+		// A single whitespace character separates the header from the samples
+		c, err := d.r.ReadByte()
+		if err != nil {
+			return header{}, err
+		}
+
+		if !isSpace(c) {
+			return header{}, fmt.Errorf("netpbm: bad header: expected whitespace before the samples, found %q", c)
+		}
+	}
+
+	return h, nil
+}
+
+// readPAMHeader reads the rest of the header of a PAM image, which is a line for each field
+func (d *decoder) readPAMHeader() (header, error) {
+	h := header{format: PAM}
+
+	var tupleType string
+
+	for {
+		field, err := d.token()
+		if err != nil {
+			return header{}, err
+		}
+
+		switch field {
+		case "WIDTH":
+			h.width, err = d.number("width")
+		case "HEIGHT":
+			h.height, err = d.number("height")
+		case "DEPTH":
+			h.depth, err = d.number("depth")
+		case "MAXVAL":
+			h.maxval, err = d.number("maxval")
+		case "TUPLTYPE":
+			tupleType, err = d.token()
+		case "ENDHDR":
+			// The rest of the line is skipped, and the samples start on the next one
+			for c := byte(0); c != '\n'; {
+				if c, err = d.r.ReadByte(); err != nil {
+					return header{}, err
+				}
+			}
+
+			if tupleType == "" && (h.depth < 1 || h.depth > 4) {
+				return header{}, fmt.Errorf("netpbm: unsupported depth %d (it must be from 1 to 4)", h.depth)
+			}
+
+			if depth, ok := tupleTypes[tupleType]; tupleType != "" && (!ok || depth != h.depth) {
+				return header{}, fmt.Errorf("netpbm: unsupported tuple type %q with depth %d", tupleType, h.depth)
+			}
+
+			return h, nil
+		default:
+			err = fmt.Errorf("netpbm: bad header: unknown field %.20q", field)
+		}
+
+		if err != nil {
+			return header{}, err
+		}
+	}
+}
+
+// Decode reads an image. Grayscale and black and white images are an *image.Gray (or an
+// *image.Gray16, if their maxval is over 255); others are an *image.NRGBA (or an *image.NRGBA64).
+func Decode(r io.Reader) (image.Image, error) {
+	d := newDecoder(r)
+
+	h, err := d.readHeader()
+	if err != nil {
+		return nil, err
+	}
+
+	img, err := d.readImage(h)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+
+	return img, err
+}
+
+// DecodeConfig gives the color model and size of an image
+func DecodeConfig(r io.Reader) (image.Config, error) {
+	h, err := newDecoder(r).readHeader()
+	if err != nil {
+		return image.Config{}, err
+	}
+
+	return h.config(), nil
+}
@@ -0,0 +1,225 @@
+package netpbm
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecode(t *testing.T) {
+	t.Parallel()
+
+	for _, test := range []struct {
+		name   string
+		data   string
+		format string
+		pixels []color.Color
+	}{
+		{"plain pbm", "P1\n# a comment\n3 1\n1 0\n1", "pbm",
+			[]color.Color{color.Gray{}, color.Gray{Y: 0xff}, color.Gray{}}},
+		{"plain pbm without spaces", "P1 3 1 101", "pbm",
+			[]color.Color{color.Gray{}, color.Gray{Y: 0xff}, color.Gray{}}},
+		{"raw pbm", "P4 3 1\n\xa0", "pbm",
+			[]color.Color{color.Gray{}, color.Gray{Y: 0xff}, color.Gray{}}},
+		{"plain pgm", "P2 3 1 15 0 5 15", "pgm",
+			[]color.Color{color.Gray{}, color.Gray{Y: 0x55}, color.Gray{Y: 0xff}}},
+		{"raw pgm", "P5 3 1 255\n\x00\x80\xff", "pgm",
+			[]color.Color{color.Gray{}, color.Gray{Y: 0x80}, color.Gray{Y: 0xff}}},
+		{"16-bit pgm", "P5 3 1 65535\n\x00\x01\x12\x34\xff\xff", "pgm",
+			[]color.Color{color.Gray16{Y: 1}, color.Gray16{Y: 0x1234}, color.Gray16{Y: 0xffff}}},
+		{"10-bit pgm", "P5 1 1 1023\n\x03\xff", "pgm", []color.Color{color.Gray16{Y: 0xffff}}},
+		{"plain ppm", "P3 2 1 255 255 0 0  0 128 64", "ppm",
+			[]color.Color{color.NRGBA{R: 0xff, A: 0xff}, color.NRGBA{G: 0x80, B: 0x40, A: 0xff}}},
+		{"raw ppm", "P6\n2 1\n255\n\xff\x00\x00\x00\x80\x40", "ppm",
+			[]color.Color{color.NRGBA{R: 0xff, A: 0xff}, color.NRGBA{G: 0x80, B: 0x40, A: 0xff}}},
+		{"16-bit ppm", "P6 1 1 65535\n\x12\x34\x56\x78\x9a\xbc", "ppm",
+			[]color.Color{color.NRGBA64{R: 0x1234, G: 0x5678, B: 0x9abc, A: 0xffff}}},
+		{"pam", "P7\nWIDTH 2\nHEIGHT 1\nDEPTH 4\nMAXVAL 255\nTUPLTYPE RGB_ALPHA\nENDHDR\n\xff\x00\x00\x80\x00\x00\xff\x00",
+			"pam", []color.Color{color.NRGBA{R: 0xff, A: 0x80}, color.NRGBA{B: 0xff}}},
+		{"16-bit pam", "P7\n# a comment\nWIDTH 1\nHEIGHT 1\nDEPTH 4\nMAXVAL 65535\nTUPLTYPE RGB_ALPHA\nENDHDR\n" +
+			"\x12\x34\x56\x78\x9a\xbc\x80\x00", "pam",
+			[]color.Color{color.NRGBA64{R: 0x1234, G: 0x5678, B: 0x9abc, A: 0x8000}}},
+		{"gray alpha pam", "P7\nWIDTH 1\nHEIGHT 1\nDEPTH 2\nMAXVAL 255\nTUPLTYPE GRAYSCALE_ALPHA\nENDHDR\n\x40\xc0",
+			"pam", []color.Color{color.NRGBA{R: 0x40, G: 0x40, B: 0x40, A: 0xc0}}},
+		{"black and white pam", "P7\nWIDTH 2\nHEIGHT 1\nDEPTH 1\nMAXVAL 1\nTUPLTYPE BLACKANDWHITE\nENDHDR\n\x00\x01",
+			"pam", []color.Color{color.Gray{}, color.Gray{Y: 0xff}}},
+		{"pam without a tuple type", "P7\nWIDTH 1\nHEIGHT 1\nDEPTH 3\nMAXVAL 255\nENDHDR\n\x01\x02\x03",
+			"pam", []color.Color{color.NRGBA{R: 1, G: 2, B: 3, A: 0xff}}},
+	} {
+		img, format, err := image.Decode(bytes.NewReader([]byte(test.data)))
+		require.NoError(t, err, test.name)
+		assert.Equal(t, test.format, format, test.name)
+		require.Equal(t, image.Rect(0, 0, len(test.pixels), 1), img.Bounds(), test.name)
+
+		for x, c := range test.pixels {
+			assert.Equal(t, c, img.At(x, 0), "%s at %d", test.name, x)
+		}
+
+		config, _, err := image.DecodeConfig(bytes.NewReader([]byte(test.data)))
+		require.NoError(t, err, test.name)
+		assert.Equal(t, img.ColorModel(), config.ColorModel, test.name)
+		assert.Equal(t, len(test.pixels), config.Width, test.name)
+		assert.Equal(t, 1, config.Height, test.name)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	t.Parallel()
+
+	for data, msg := range map[string]string{
+		"P8 1 1":              "netpbm: not a Netpbm image",
+		"P5 1 one 255\n\x00":  `netpbm: bad header: expected the height, found "one"`,
+		"P5 0 1 255\n":        "netpbm: bad header: the size is 0x1",
+		"P5 1 1 65536\n\x00":  "netpbm: bad header: the maxval is 65536 (it must be from 1 to 65535)",
+		"P5 1 1 255#\n\x00":   `netpbm: bad header: expected whitespace before the samples, found '#'`,
+		"P2 1 1 15 16":        "netpbm: a sample is 16, but the maxval is 15",
+		"P5 1 1 15\n\x10":     "netpbm: a sample is 16, but the maxval is 15",
+		"P2 1 1 15 x":         `netpbm: expected a sample, found "x"`,
+		"P1 1 1 2":            "netpbm: expected a 0 or a 1, found '2'",
+		"P5 100000 100000 1 ": "netpbm: the image is too big (100000x100000)",
+		"P7\nWIDTH 1\nHEIGHT 1\nDEPTH 3\nMAXVAL 255\nTUPLTYPE GRAYSCALE\nENDHDR\n": `netpbm: unsupported tuple type "GRAYSCALE" with depth 3`,
+		"P7\nWIDTH 1\nHEIGHT 1\nDEPTH 5\nMAXVAL 255\nENDHDR\n":                     "netpbm: unsupported depth 5 (it must be from 1 to 4)",
+		"P7\nWIDTH 1\nHEIGHT 1\nCOLORS 3\nENDHDR\n":                                `netpbm: bad header: unknown field "COLORS"`,
+	} {
+		_, err := Decode(bytes.NewReader([]byte(data)))
+		assert.EqualError(t, err, msg, "%q", data)
+	}
+
+	// Images that stop part of the way through
+	for _, data := range []string{"P5 2 1", "P5 2 1 255\n\x00", "P2 2 1 255 0", "P4 9 1\n\x00",
+		"P7\nWIDTH 1\nHEIGHT 1\nDEPTH 1\nMAXVAL 255\n"} {
+		_, err := Decode(bytes.NewReader([]byte(data)))
+		assert.Equal(t, io.ErrUnexpectedEOF, err, "%q", data)
+	}
+}
+
+// testImages are images of each color model that's written differently
+func testImages() map[string]image.Image {
+	gray := image.NewGray(image.Rect(0, 0, 3, 2))
+	gray16 := image.NewGray16(image.Rect(0, 0, 3, 2))
+	opaque := image.NewNRGBA(image.Rect(0, 0, 3, 2))
+	translucent := image.NewNRGBA(image.Rect(0, 0, 3, 2))
+	translucent16 := image.NewNRGBA64(image.Rect(0, 0, 3, 2))
+
+	for i := 0; i < 6; i++ {
+		x, y := i%3, i/3
+		gray.SetGray(x, y, color.Gray{Y: uint8(i * 50)})
+		gray16.SetGray16(x, y, color.Gray16{Y: uint16(i * 12345)})
+		opaque.SetNRGBA(x, y, color.NRGBA{R: uint8(i * 50), G: uint8(255 - i), B: 0x40, A: 0xff})
+		translucent.SetNRGBA(x, y, color.NRGBA{R: uint8(i * 50), G: uint8(255 - i), B: 0x40, A: uint8(i * 40)})
+		translucent16.SetNRGBA64(x, y, color.NRGBA64{R: uint16(i * 12345), G: 0x1234, B: 0xfedc,
+			A: uint16(0xffff - i*999)})
+	}
+
+	return map[string]image.Image{
+		"gray":          gray,
+		"gray16":        gray16,
+		"opaque":        opaque,
+		"translucent":   translucent,
+		"translucent16": translucent16,
+	}
+}
+
+// assertSameColors checks that two images have the same colors, once they're both converted to
+// model
+func assertSameColors(t *testing.T, expected, actual image.Image, model color.Model, msg string) {
+	eb, ab := expected.Bounds(), actual.Bounds()
+	require.Equal(t, eb.Size(), ab.Size(), msg)
+
+	for y := 0; y < eb.Dy(); y++ {
+		for x := 0; x < eb.Dx(); x++ {
+			assert.Equal(t, model.Convert(expected.At(eb.Min.X+x, eb.Min.Y+y)),
+				model.Convert(actual.At(ab.Min.X+x, ab.Min.Y+y)), "%s at %d,%d", msg, x, y)
+		}
+	}
+}
+
+// overBlack converts colors to how they look over black
+var overBlack = color.ModelFunc(func(c color.Color) color.Color {
+	r, g, b, _ := c.RGBA()
+
+	return color.RGBA64{R: uint16(r), G: uint16(g), B: uint16(b), A: 0xffff}
+})
+
+// overBlack8 converts colors to how they look over black, with 8 bits of each channel
+var overBlack8 = color.ModelFunc(func(c color.Color) color.Color {
+	r, g, b, _ := c.RGBA()
+
+	return color.RGBA{R: uint8(r >> 8), G: uint8(g >> 8), B: uint8(b >> 8), A: 0xff}
+})
+
+func TestRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	// The formats that images are written in when no format is given
+	expectedFormats := map[string]string{
+		"gray":          "pgm",
+		"gray16":        "pgm",
+		"opaque":        "ppm",
+		"translucent":   "pam",
+		"translucent16": "pam",
+	}
+
+	for name, img := range testImages() {
+		// With no options, everything about each image is kept
+		out := &bytes.Buffer{}
+		require.NoError(t, Encode(out, img, nil), name)
+
+		decoded, format, err := image.Decode(out)
+		require.NoError(t, err, name)
+		assert.Equal(t, expectedFormats[name], format, name)
+		assertSameColors(t, img, decoded, color.NRGBA64Model, name)
+		assert.Equal(t, is16Bit(img), is16Bit(decoded), name)
+
+		// PAM keeps everything, too
+		out.Reset()
+		require.NoError(t, Encode(out, img, &Options{Format: PAM}), name)
+
+		decoded, format, err = image.Decode(out)
+		require.NoError(t, err, name)
+		assert.Equal(t, "pam", format, name)
+		assertSameColors(t, img, decoded, color.NRGBA64Model, name)
+
+		// The others lose alpha, and color
+		for format, model := range map[Format]color.Model{PPM: overBlack, PGM: color.Gray16Model} {
+			out.Reset()
+			require.NoError(t, Encode(out, img, &Options{Format: format}), name)
+
+			decoded, err := Decode(out)
+			require.NoError(t, err, name)
+
+			if !is16Bit(img) {
+				// 8-bit colors only keep 8 bits when they're converted
+				model = map[Format]color.Model{PPM: overBlack8, PGM: color.GrayModel}[format]
+			}
+
+			assertSameColors(t, img, decoded, model, name+" as "+format.String())
+		}
+	}
+}
+
+func TestEncodePBM(t *testing.T) {
+	t.Parallel()
+
+	img := image.NewGray(image.Rect(5, 5, 14, 6))
+	for x := 5; x < 14; x++ {
+		img.SetGray(x, 5, color.Gray{Y: uint8(x%2) * 0xff})
+	}
+
+	out := &bytes.Buffer{}
+	require.NoError(t, Encode(out, img, &Options{Format: PBM}))
+	assert.Equal(t, "P4\n9 1\n\x55\x00", out.String())
+
+	decoded, format, err := image.Decode(out)
+	require.NoError(t, err)
+	assert.Equal(t, "pbm", format)
+	assertSameColors(t, img, decoded, color.GrayModel, "pbm")
+
+	assert.EqualError(t, Encode(out, image.NewGray(image.Rect(0, 0, 0, 3)), nil),
+		"netpbm: can't write an image that's 0x3")
+}
@@ -0,0 +1,182 @@
+// Package quantize picks palettes for images that have more colors than a paletted format (such as
+// GIF) can hold.
+package quantize
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"sort"
+)
+
+var _ draw.Quantizer = MedianCut{}
+
+// MedianCut is a draw.Quantizer that uses Heckbert's median cut: it repeatedly splits the box of
+// colors with the widest range of any channel in two, at the median of that channel, and uses the
+// average color of each box. If an image has few enough colors, they're all used as is.
+//
+// The palette it gives for an image is always the same.
+type MedianCut struct{}
+
+// entry is one of the distinct colors of an image, and how many pixels have it
+type entry struct {
+	c     color.RGBA
+	count int
+}
+
+func channel(c color.RGBA, i int) uint8 {
+	switch i {
+	case 0:
+		return c.R
+	case 1:
+		return c.G
+	case 2:
+		return c.B
+	}
+
+	return c.A
+}
+
+// Quantize appends up to cap(p)-len(p) colors to p, for converting m to a paletted image.
+func (MedianCut) Quantize(p color.Palette, m image.Image) color.Palette {
+	n := cap(p) - len(p)
+	if n <= 0 {
+		return p
+	}
+
+	entries := histogram(m)
+
+	if len(entries) <= n {
+		for _, e := range entries {
+			p = append(p, e.c)
+		}
+
+		return p
+	}
+
+	boxes := []box{entries}
+	for len(boxes) < n {
+		// Split the box with the widest range
+		widest, widestRange := -1, 0
+		for i, b := range boxes {
+			if len(b) < 2 {
+				continue
+			}
+
+			if _, r := b.widestChannel(); r > widestRange {
+				widest, widestRange = i, r
+			}
+		}
+
+		if widest < 0 {
+			break
+		}
+
+		a, b := boxes[widest].split()
+		boxes[widest] = a
+		boxes = append(boxes, b)
+	}
+
+	for _, b := range boxes {
+		p = append(p, b.average())
+	}
+
+	return p
+}
+
+// histogram gives the distinct colors of m (as 8-bit, alpha-premultiplied colors), in a fixed order
+func histogram(m image.Image) []entry {
+	counts := map[color.RGBA]int{}
+
+	bounds := m.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			counts[color.RGBAModel.Convert(m.At(x, y)).(color.RGBA)]++
+		}
+	}
+
+	entries := make([]entry, 0, len(counts))
+	for c, count := range counts {
+		entries = append(entries, entry{c: c, count: count})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return packed(entries[i].c) < packed(entries[j].c)
+	})
+
+	return entries
+}
+
+func packed(c color.RGBA) uint32 {
+	return uint32(c.R)<<24 | uint32(c.G)<<16 | uint32(c.B)<<8 | uint32(c.A)
+}
+
+// box is a group of colors that become one color of the palette
+type box []entry
+
+// widestChannel gives the channel (0 to 3, for R, G, B and A) that the colors of the box have the
+// widest range of, and that range
+func (b box) widestChannel() (ch, width int) {
+	for i := 0; i < 4; i++ {
+		lo, hi := uint8(0xff), uint8(0)
+		for _, e := range b {
+			v := channel(e.c, i)
+			if v < lo {
+				lo = v
+			}
+
+			if v > hi {
+				hi = v
+			}
+		}
+
+		if int(hi)-int(lo) > width {
+			ch, width = i, int(hi)-int(lo)
+		}
+	}
+
+	return ch, width
+}
+
+// split splits the box in two at the median (by pixel count) of its widest channel. Both halves
+// have at least one color.
+func (b box) split() (box, box) {
+	ch, _ := b.widestChannel()
+
+	sort.SliceStable(b, func(i, j int) bool {
+		return channel(b[i].c, ch) < channel(b[j].c, ch)
+	})
+
+	total := 0
+	for _, e := range b {
+		total += e.count
+	}
+
+	at, seen := 1, b[0].count
+	for at < len(b)-1 && seen < total/2 {
+		seen += b[at].count
+		at++
+	}
+
+	return b[:at:at], b[at:]
+}
+
+// average gives the average color of the pixels in the box
+func (b box) average() color.RGBA {
+	var r, g, bl, a, total uint64
+	for _, e := range b {
+		count := uint64(e.count)
+		r += uint64(e.c.R) * count
+		g += uint64(e.c.G) * count
+		bl += uint64(e.c.B) * count
+		a += uint64(e.c.A) * count
+		total += count
+	}
+
+	return color.RGBA{
+		R: uint8((r + total/2) / total),
+		G: uint8((g + total/2) / total),
+		B: uint8((bl + total/2) / total),
+		A: uint8((a + total/2) / total),
+	}
+}
@@ -0,0 +1,75 @@
+package quantize
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFewColors(t *testing.T) {
+	t.Parallel()
+
+	img := image.NewNRGBA(image.Rect(0, 0, 4, 4))
+	img.Set(0, 0, color.NRGBA{R: 0xff, A: 0xff})
+	img.Set(1, 0, color.NRGBA{B: 0xff, A: 0x80})
+
+	// Few enough colors are kept exactly
+	p := MedianCut{}.Quantize(make(color.Palette, 0, 256), img)
+	assert.ElementsMatch(t, color.Palette{
+		color.RGBA{},
+		color.RGBA{R: 0xff, A: 0xff},
+		color.RGBA{B: 0x80, A: 0x80},
+	}, p)
+
+	// Colors that are already there are kept
+	p = MedianCut{}.Quantize(append(make(color.Palette, 0, 4), color.White), img)
+	require.Len(t, p, 4)
+	assert.Equal(t, color.White, p[0])
+}
+
+func TestManyColors(t *testing.T) {
+	t.Parallel()
+
+	img := image.NewNRGBA(image.Rect(0, 0, 64, 64))
+	for y := 0; y < 64; y++ {
+		for x := 0; x < 64; x++ {
+			img.Set(x, y, color.NRGBA{R: uint8(x * 4), G: uint8(y * 4), B: 0x80, A: 0xff})
+		}
+	}
+
+	for _, n := range []int{2, 16, 256} {
+		p := MedianCut{}.Quantize(make(color.Palette, 0, n), img)
+		assert.Len(t, p, n)
+
+		// The palette is always the same
+		assert.Equal(t, p, MedianCut{}.Quantize(make(color.Palette, 0, n), img))
+
+		// Every pixel has a palette entry nearby
+		maxDist := 0
+		for y := 0; y < 64; y += 7 {
+			for x := 0; x < 64; x += 7 {
+				c := img.NRGBAAt(x, y)
+				nearest := p.Convert(c).(color.RGBA)
+				dist := abs(int(c.R)-int(nearest.R)) + abs(int(c.G)-int(nearest.G))
+				if dist > maxDist {
+					maxDist = dist
+				}
+			}
+		}
+
+		// The colors form a square, so each of n boxes covers about 1/sqrt(n) of each side
+		assert.True(t, float64(maxDist) <= 300/math.Sqrt(float64(n)), "n=%d: a pixel is %d from its nearest palette entry", n, maxDist)
+	}
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+
+	return v
+}
@@ -0,0 +1,89 @@
+package sortablecolor
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+)
+
+// Depth is the number of bits per channel of an image
+type Depth int
+
+const (
+	// SameDepth is the depth of the source image
+	SameDepth Depth = 0
+
+	// Depth8 is 8 bits per channel
+	Depth8 Depth = 8
+
+	// Depth16 is 16 bits per channel
+	Depth16 Depth = 16
+)
+
+func (d Depth) String() string {
+	if d == SameDepth {
+		return "auto"
+	}
+
+	return fmt.Sprintf("%d", int(d))
+}
+
+// ParseDepth returns the Depth with the given name (as returned by Depth.String)
+func ParseDepth(name string) (Depth, error) {
+	for _, d := range []Depth{SameDepth, Depth8, Depth16} {
+		if name == d.String() {
+			return d, nil
+		}
+	}
+
+	return 0, fmt.Errorf("unknown depth %q (expected auto, 8 or 16)", name)
+}
+
+// DepthOf gives the depth of img. Anything that isn't known to be 16-bit is taken to be 8-bit.
+func DepthOf(img image.Image) Depth {
+	switch img.ColorModel() {
+	case color.RGBA64Model, color.NRGBA64Model, color.Gray16Model, color.Alpha16Model:
+		return Depth16
+	}
+
+	return Depth8
+}
+
+// isGray is true if every color of img is a shade of gray
+func isGray(img image.Image) bool {
+	switch img.ColorModel() {
+	case color.GrayModel, color.Gray16Model:
+		return true
+	}
+
+	return false
+}
+
+// NewImageLike creates an image with the given bounds to write the sorted pixels of src to. It's
+// grayscale if src is, and it has the given depth (or the depth of src, for SameDepth). Colors are
+// stored without alpha-premultiplication, so translucent colors lose as little as they can.
+//
+// If src is an *image.Paletted, the new image is too, with the same palette, unless a depth of 16
+// is asked for.
+func NewImageLike(src image.Image, bounds image.Rectangle, depth Depth) SettableImage {
+	if paletted, ok := src.(*image.Paletted); ok && depth != Depth16 {
+		return image.NewPaletted(bounds, paletted.Palette)
+	}
+
+	if depth == SameDepth {
+		depth = DepthOf(src)
+	}
+
+	switch {
+	case isGray(src) && depth == Depth16:
+		return image.NewGray16(bounds)
+
+	case isGray(src):
+		return image.NewGray(bounds)
+
+	case depth == Depth16:
+		return image.NewNRGBA64(bounds)
+	}
+
+	return image.NewNRGBA(bounds)
+}
@@ -0,0 +1,54 @@
+package sortablecolor
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewImageLike(t *testing.T) {
+	t.Parallel()
+
+	r := image.Rect(0, 0, 2, 2)
+	bounds := image.Rect(1, 2, 3, 4)
+
+	for _, test := range []struct {
+		src      image.Image
+		depth    Depth
+		expected image.Image
+	}{
+		{image.NewGray(r), SameDepth, image.NewGray(bounds)},
+		{image.NewGray16(r), SameDepth, image.NewGray16(bounds)},
+		{image.NewGray(r), Depth16, image.NewGray16(bounds)},
+		{image.NewGray16(r), Depth8, image.NewGray(bounds)},
+		{image.NewNRGBA(r), SameDepth, image.NewNRGBA(bounds)},
+		{image.NewRGBA(r), SameDepth, image.NewNRGBA(bounds)},
+		{image.NewYCbCr(r, image.YCbCrSubsampleRatio420), SameDepth, image.NewNRGBA(bounds)},
+		{image.NewPaletted(r, color.Palette{color.Black}), SameDepth, image.NewPaletted(bounds, color.Palette{color.Black})},
+		{image.NewPaletted(r, color.Palette{color.Black}), Depth8, image.NewPaletted(bounds, color.Palette{color.Black})},
+		{image.NewPaletted(r, color.Palette{color.Black}), Depth16, image.NewNRGBA64(bounds)},
+		{image.NewCMYK(r), SameDepth, image.NewNRGBA(bounds)},
+		{image.NewNRGBA64(r), SameDepth, image.NewNRGBA64(bounds)},
+		{image.NewRGBA64(r), SameDepth, image.NewNRGBA64(bounds)},
+		{image.NewRGBA64(r), Depth8, image.NewNRGBA(bounds)},
+		{image.NewNRGBA(r), Depth16, image.NewNRGBA64(bounds)},
+	} {
+		assert.Equal(t, test.expected, NewImageLike(test.src, bounds, test.depth), "%T at %v", test.src, test.depth)
+	}
+}
+
+func TestParseDepth(t *testing.T) {
+	t.Parallel()
+
+	for _, d := range []Depth{SameDepth, Depth8, Depth16} {
+		parsed, err := ParseDepth(d.String())
+		require.NoError(t, err)
+		assert.Equal(t, d, parsed)
+	}
+
+	_, err := ParseDepth("32")
+	assert.EqualError(t, err, `unknown depth "32" (expected auto, 8 or 16)`)
+}
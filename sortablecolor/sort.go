@@ -0,0 +1,186 @@
+package sortablecolor
+
+import (
+	"fmt"
+	"image"
+	"sort"
+	"strings"
+)
+
+// Mode is how the pixels of an image are split up to be sorted
+type Mode int
+
+const (
+	// Global sorts all of the pixels of an image together, so they fill it row by row
+	Global Mode = iota
+
+	// Rows sorts each row of an image separately
+	Rows
+
+	// Columns sorts each column of an image separately
+	Columns
+
+	// Intervals sorts runs of neighboring pixels within each row, where every pixel in a run has a
+	// value within the thresholds. The pixels outside of the thresholds stay where they are.
+	Intervals
+)
+
+// Modes are all of the known Modes
+var Modes = []Mode{Global, Rows, Columns, Intervals}
+
+func (m Mode) String() string {
+	switch m {
+	case Global:
+		return "global"
+	case Rows:
+		return "rows"
+	case Columns:
+		return "columns"
+	case Intervals:
+		return "intervals"
+	}
+
+	return fmt.Sprintf("Mode(%d)", int(m))
+}
+
+// ParseMode returns the Mode with the given name (as returned by Mode.String)
+func ParseMode(name string) (Mode, error) {
+	names := make([]string, len(Modes))
+	for i, m := range Modes {
+		if strings.EqualFold(name, m.String()) {
+			return m, nil
+		}
+
+		names[i] = m.String()
+	}
+
+	return 0, fmt.Errorf("unknown sort mode %q (expected one of %s)", name, strings.Join(names, ", "))
+}
+
+// Options are how to sort a SortableBuffer
+type Options struct {
+	Mode Mode
+
+	// Descending puts the largest values first, rather than the smallest
+	Descending bool
+
+	// Stable keeps pixels with equal values in the order they were in, which is slower, but gives
+	// the same result for the same values every time
+	Stable bool
+
+	// Lower and Upper are the thresholds for Intervals mode; only pixels with values in
+	// [Lower, Upper] are sorted
+	Lower, Upper uint64
+
+	// BlockSize, if it's more than zero, splits everything that would be sorted into blocks of
+	// this many pixels, which are sorted separately. Sorting with blocks of 1, 2, 4, 8 and so on
+	// (with Stable) gives each pass of a bottom-up merge sort.
+	BlockSize int
+
+	// Mask, if it's set, says which pixels are in intervals for Intervals mode (by their position in
+	// the buffer), instead of the thresholds. See TemporalMask.
+	Mask []bool
+}
+
+// Sort sorts the buffer, which holds the pixels of an image with the given bounds in row order (as
+// from SortableBufferFromImage).
+func (buf SortableBuffer) Sort(bounds image.Rectangle, opts Options) {
+	width, height := bounds.Dx(), bounds.Dy()
+
+	switch opts.Mode {
+	case Global:
+		opts.sort(&run{buf: buf, step: 1, n: len(buf), descending: opts.Descending})
+
+	case Rows:
+		for y := 0; y < height; y++ {
+			opts.sort(&run{buf: buf, start: y * width, step: 1, n: width, descending: opts.Descending})
+		}
+
+	case Columns:
+		for x := 0; x < width; x++ {
+			opts.sort(&run{buf: buf, start: x, step: width, n: height, descending: opts.Descending})
+		}
+
+	case Intervals:
+		for y := 0; y < height; y++ {
+			row := y * width
+
+			for x := 0; x < width; {
+				if !opts.inInterval(buf, row+x) {
+					x++
+					continue
+				}
+
+				start := x
+				for x < width && opts.inInterval(buf, row+x) {
+					x++
+				}
+
+				opts.sort(&run{buf: buf, start: row + start, step: 1, n: x - start, descending: opts.Descending})
+			}
+		}
+
+	default:
+		panic(fmt.Sprintf("sortablecolor: unknown sort mode %v", opts.Mode))
+	}
+}
+
+func (opts Options) sort(r *run) {
+	if opts.BlockSize <= 0 || opts.BlockSize >= r.n {
+		opts.sortRun(r)
+		return
+	}
+
+	for i := 0; i < r.n; i += opts.BlockSize {
+		block := *r
+		block.start = r.start + i*r.step
+		block.n = opts.BlockSize
+		if r.n-i < block.n {
+			block.n = r.n - i
+		}
+
+		opts.sortRun(&block)
+	}
+}
+
+func (opts Options) sortRun(data sort.Interface) {
+	if opts.Stable {
+		sort.Stable(data)
+	} else {
+		sort.Sort(data)
+	}
+}
+
+func (opts Options) inInterval(buf SortableBuffer, i int) bool {
+	if opts.Mask != nil {
+		return opts.Mask[i]
+	}
+
+	return opts.Lower <= buf[i].v && buf[i].v <= opts.Upper
+}
+
+var _ sort.Interface = (*run)(nil)
+
+// run is a sort.Interface over n of the pixels of a SortableBuffer, starting at start and step
+// apart
+type run struct {
+	buf            SortableBuffer
+	start, step, n int
+	descending     bool
+}
+
+func (r *run) Len() int {
+	return r.n
+}
+
+func (r *run) Less(i, j int) bool {
+	if r.descending {
+		i, j = j, i
+	}
+
+	return r.buf.Less(r.start+i*r.step, r.start+j*r.step)
+}
+
+func (r *run) Swap(i, j int) {
+	r.buf.Swap(r.start+i*r.step, r.start+j*r.step)
+}
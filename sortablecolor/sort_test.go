@@ -0,0 +1,195 @@
+package sortablecolor
+
+import (
+	"image"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// bufferOf makes a SortableBuffer with the given values
+func bufferOf(values ...uint64) SortableBuffer {
+	buf := make(SortableBuffer, len(values))
+	for i, v := range values {
+		buf[i].v = v
+	}
+
+	return buf
+}
+
+func valuesOf(buf SortableBuffer) []uint64 {
+	values := make([]uint64, len(buf))
+	for i := range buf {
+		values[i] = buf[i].v
+	}
+
+	return values
+}
+
+func TestSort(t *testing.T) {
+	t.Parallel()
+
+	// 4 wide, 3 high
+	bounds := image.Rect(10, 20, 14, 23)
+	values := []uint64{
+		5, 1, 9, 3,
+		8, 2, 7, 0,
+		4, 6, 1, 9,
+	}
+
+	for _, test := range []struct {
+		opts     Options
+		expected []uint64
+	}{
+		{
+			opts: Options{Mode: Global},
+			expected: []uint64{
+				0, 1, 1, 2,
+				3, 4, 5, 6,
+				7, 8, 9, 9,
+			},
+		},
+		{
+			opts: Options{Mode: Global, Descending: true},
+			expected: []uint64{
+				9, 9, 8, 7,
+				6, 5, 4, 3,
+				2, 1, 1, 0,
+			},
+		},
+		{
+			opts: Options{Mode: Rows},
+			expected: []uint64{
+				1, 3, 5, 9,
+				0, 2, 7, 8,
+				1, 4, 6, 9,
+			},
+		},
+		{
+			opts: Options{Mode: Columns, Descending: true},
+			expected: []uint64{
+				8, 6, 9, 9,
+				5, 2, 7, 3,
+				4, 1, 1, 0,
+			},
+		},
+		{
+			// Only runs of values in [2, 8] are sorted
+			opts: Options{Mode: Intervals, Lower: 2, Upper: 8},
+			expected: []uint64{
+				5, 1, 9, 3,
+				2, 7, 8, 0,
+				4, 6, 1, 9,
+			},
+		},
+		{
+			opts: Options{Mode: Intervals, Lower: 2, Upper: 8, Descending: true},
+			expected: []uint64{
+				5, 1, 9, 3,
+				8, 7, 2, 0,
+				6, 4, 1, 9,
+			},
+		},
+	} {
+		buf := bufferOf(values...)
+		buf.Sort(bounds, test.opts)
+
+		assert.Equal(t, test.expected, valuesOf(buf), "%+v", test.opts)
+	}
+}
+
+func TestParseMode(t *testing.T) {
+	t.Parallel()
+
+	for _, m := range Modes {
+		parsed, err := ParseMode(m.String())
+		require.NoError(t, err)
+		assert.Equal(t, m, parsed)
+	}
+
+	_, err := ParseMode("diagonal")
+	assert.EqualError(t, err, `unknown sort mode "diagonal" (expected one of global, rows, columns, intervals)`)
+}
+
+func TestSortStable(t *testing.T) {
+	t.Parallel()
+
+	buf := bufferOf(2, 1, 2, 1, 2, 1, 2, 1, 2, 1, 2, 1, 2, 1, 2, 1)
+	for i := range buf {
+		buf[i].index = uint8(i)
+	}
+
+	buf.Sort(image.Rect(0, 0, len(buf), 1), Options{Mode: Rows, Descending: true, Stable: true})
+
+	indexes := make([]uint8, len(buf))
+	for i := range buf {
+		indexes[i] = buf[i].index
+	}
+
+	assert.Equal(t, []uint8{0, 2, 4, 6, 8, 10, 12, 14, 1, 3, 5, 7, 9, 11, 13, 15}, indexes)
+}
+
+func TestSortBlocks(t *testing.T) {
+	t.Parallel()
+
+	values := []uint64{7, 3, 5, 1, 8, 2, 6, 4, 9, 0}
+	bounds := image.Rect(0, 0, 5, 2)
+
+	buf := bufferOf(values...)
+	buf.Sort(bounds, Options{Mode: Rows, BlockSize: 2})
+	assert.Equal(t, []uint64{3, 7, 1, 5, 8, 2, 6, 4, 9, 0}, valuesOf(buf))
+
+	buf = bufferOf(values...)
+	buf.Sort(bounds, Options{Mode: Global, BlockSize: 4})
+	assert.Equal(t, []uint64{1, 3, 5, 7, 2, 4, 6, 8, 0, 9}, valuesOf(buf))
+
+	// Sorting with bigger and bigger blocks, each pass on the result of the one before, ends up
+	// the same as sorting in one go
+	stepped := bufferOf(values...)
+	for size := 1; size < len(values)*2; size *= 2 {
+		stepped.Sort(bounds, Options{Mode: Global, Stable: true, BlockSize: size})
+	}
+
+	whole := bufferOf(values...)
+	whole.Sort(bounds, Options{Mode: Global, Stable: true})
+	assert.Equal(t, valuesOf(whole), valuesOf(stepped))
+}
+
+func TestSortMask(t *testing.T) {
+	t.Parallel()
+
+	buf := bufferOf(5, 1, 4, 2, 3, 9)
+	buf.Sort(image.Rect(0, 0, 6, 1), Options{
+		Mode:  Intervals,
+		Lower: 0, Upper: 100,
+		Mask: []bool{true, true, false, true, true, true},
+	})
+
+	assert.Equal(t, []uint64{1, 5, 4, 2, 3, 9}, valuesOf(buf))
+}
+
+func TestTemporalMask(t *testing.T) {
+	t.Parallel()
+
+	opts := Options{Mode: Intervals, Lower: 10, Upper: 20}
+
+	tm := &TemporalMask{Hysteresis: 2}
+	assert.Equal(t, []bool{false, true, true, false}, tm.Next(bufferOf(9, 10, 20, 21), opts))
+
+	// Pixels that were in an interval stay in it a little way past the thresholds, but ones that
+	// weren't have to get within them
+	assert.Equal(t, []bool{false, true, true, false}, tm.Next(bufferOf(8, 8, 22, 22), opts))
+	assert.Equal(t, []bool{true, false, false, false}, tm.Next(bufferOf(10, 7, 23, 22), opts))
+
+	// With smoothing, a pixel has to stay past a threshold for a while before it's out
+	tm = &TemporalMask{Smoothing: 0.5}
+	assert.Equal(t, []bool{true}, tm.Next(bufferOf(20), opts))
+	assert.Equal(t, []bool{true}, tm.Next(bufferOf(20), opts))
+	assert.Equal(t, []bool{false}, tm.Next(bufferOf(30), opts))
+	assert.Equal(t, []bool{true}, tm.Next(bufferOf(10), opts))
+	assert.Equal(t, []bool{true}, tm.Next(bufferOf(22), opts))
+
+	// A frame of a different size starts over
+	assert.Equal(t, []bool{false, true}, tm.Next(bufferOf(30, 15), opts))
+}
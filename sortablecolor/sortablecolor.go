@@ -14,6 +14,13 @@ type SortableColor struct {
 
 	// v is an absolute value representing the apparent brightness of this color
 	v uint64
+
+	// index is the index of Color in the palette of the image it came from, if paletted is true
+	index    uint8
+	paletted bool
+
+	// origin is where in the buffer the color was read into, before it was sorted
+	origin int
 }
 
 // Set assigns the color (and relative brightness) of this instance
@@ -23,6 +30,21 @@ func (sc *SortableColor) Set(c color.Color, combiner combiner.Combiner) {
 	sc.v = combiner.Combine(c)
 }
 
+// SetAt assigns the color (and relative brightness) of this instance from the pixel at (x, y) in
+// img, for a combiner that takes the pixel's position or surroundings into account
+func (sc *SortableColor) SetAt(img image.Image, x, y int, combiner combiner.ImageCombiner) {
+	sc.Color = img.At(x, y)
+
+	sc.v = combiner.CombineAt(img, x, y)
+}
+
+// Origin is the position that the color had in the buffer that SortableBufferFromImage read it
+// into, before the buffer was sorted. Comparing it with the color's position after sorting gives
+// how far the pixel moved.
+func (sc *SortableColor) Origin() int {
+	return sc.origin
+}
+
 // Compare compares the relative brightness of SortableColor to another SortableColor
 func (sc *SortableColor) Compare(sc2 SortableColor) int {
 	if sc.v < sc2.v {
@@ -40,43 +62,148 @@ var _ sort.Interface = SortableBuffer(nil)
 // Implements http://golang.org/pkg/sort/#Interface
 type SortableBuffer []SortableColor
 
-// SortableBufferFromImage reads in image into a SortableBuffer
-func SortableBufferFromImage(img image.Image, combiner combiner.Combiner) (SortableBuffer, image.Rectangle) {
+// SortableBufferFromImage reads in image into a SortableBuffer. If cmb is a
+// combiner.ImageCombiner, each pixel is combined with CombineAt.
+func SortableBufferFromImage(img image.Image, cmb combiner.Combiner) (SortableBuffer, image.Rectangle) {
 	bounds := img.Bounds()
 
 	// Allocate the memory for the buffer we're going to sort
 	buffer := make(SortableBuffer, bounds.Dx()*bounds.Dy())
+	for i := range buffer {
+		buffer[i].origin = i
+	}
+
+	if paletted, ok := img.(*image.Paletted); ok {
+		buffer.readPaletted(paletted, cmb)
+
+		return buffer, bounds
+	}
+
+	if imgCmb, ok := cmb.(combiner.ImageCombiner); ok {
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			bufY := buffer[(y-bounds.Min.Y)*bounds.Dx():]
+
+			for x := bounds.Min.X; x < bounds.Max.X; x++ {
+				bufY[x-bounds.Min.X].SetAt(img, x, y, imgCmb)
+			}
+		}
+
+		return buffer, bounds
+	}
 
 	// Read the image into the buffer
-	for y := 0; y < bounds.Dy(); y++ {
-		bufY := buffer[y*bounds.Dx():]
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		bufY := buffer[(y-bounds.Min.Y)*bounds.Dx():]
 
-		for x := 0; x < bounds.Dx(); x++ {
-			bufY[x].Set(img.At(x, y), combiner)
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			bufY[x-bounds.Min.X].Set(img.At(x, y), cmb)
 		}
 	}
 
 	return buffer, bounds
 }
 
+// readPaletted reads a paletted image into the buffer, keeping the palette index of each pixel.
+// Unless cmb is a combiner.ImageCombiner, each entry of the palette only has to be combined once.
+func (buf SortableBuffer) readPaletted(img *image.Paletted, cmb combiner.Combiner) {
+	bounds := img.Bounds()
+	src := img
+
+	imgCmb, isImgCmb := cmb.(combiner.ImageCombiner)
+	if isImgCmb {
+		// An image.Paletted panics when asked for the color of an index past the end of its
+		// palette, so an ImageCombiner gets an image where those are transparent black
+		src = withFullPalette(img)
+	}
+
+	var keys []uint64
+	if !isImgCmb {
+		keys = make([]uint64, len(img.Palette))
+		for i, c := range img.Palette {
+			keys[i] = cmb.Combine(c)
+		}
+	}
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		bufY := buf[(y-bounds.Min.Y)*bounds.Dx():]
+
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			sc := &bufY[x-bounds.Min.X]
+			sc.index = img.ColorIndexAt(x, y)
+
+			// An index past the end of the palette has no color, so it's taken to be transparent
+			// black, and isn't kept
+			sc.paletted = int(sc.index) < len(img.Palette)
+			if sc.paletted {
+				sc.Color = img.Palette[sc.index]
+			} else {
+				sc.Color = color.Transparent
+			}
+
+			switch {
+			case isImgCmb:
+				sc.v = imgCmb.CombineAt(src, x, y)
+			case sc.paletted:
+				sc.v = keys[sc.index]
+			default:
+				sc.v = cmb.Combine(sc.Color)
+			}
+		}
+	}
+}
+
+// withFullPalette gives img, or if any of its pixels have an index past the end of its palette, a
+// copy of img (sharing its pixels) whose palette has transparent black for every missing entry
+func withFullPalette(img *image.Paletted) *image.Paletted {
+	bounds := img.Bounds()
+
+	highest := -1
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if idx := int(img.ColorIndexAt(x, y)); idx > highest {
+				highest = idx
+			}
+		}
+	}
+
+	if highest < len(img.Palette) {
+		return img
+	}
+
+	full := *img
+	full.Palette = append(make(color.Palette, 0, highest+1), img.Palette...)
+	for len(full.Palette) <= highest {
+		full.Palette = append(full.Palette, color.Transparent)
+	}
+
+	return &full
+}
+
 // SettableImage represents an image.Image with the ability to set color at specific pixels
 type SettableImage interface {
 	image.Image
 	Set(x, y int, c color.Color)
 }
 
-// ToImage writes the contents of the buffer out to the provided image using its bounds.
+// ToImage writes the contents of the buffer out to the provided image using its bounds. If img is
+// an *image.Paletted with the same palette as the image that the buffer was read from, the palette
+// indexes are copied as they are.
 func (buf SortableBuffer) ToImage(img SettableImage) {
 	bounds := img.Bounds()
 
+	if paletted, ok := img.(*image.Paletted); ok {
+		buf.toPaletted(paletted)
+		return
+	}
+
 	var c color.Color
 
 	// Write it back out to the image
-	for y := 0; y < bounds.Dy(); y++ {
-		bufY := buf[y*bounds.Dx():]
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		bufY := buf[(y-bounds.Min.Y)*bounds.Dx():]
 
-		for x := 0; x < bounds.Dx(); x++ {
-			c = bufY[x].Color
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c = bufY[x-bounds.Min.X].Color
 			img.Set(x, y, img.ColorModel().Convert(c))
 		}
 	}
@@ -93,3 +220,24 @@ func (buf SortableBuffer) Less(i, j int) bool {
 func (buf SortableBuffer) Swap(i, j int) {
 	buf[i], buf[j] = buf[j], buf[i]
 }
+
+// toPaletted writes the contents of the buffer out to a paletted image. Colors whose palette index
+// is known, and has the same color in img's palette, keep their index; others get the index of the
+// nearest color in img's palette.
+func (buf SortableBuffer) toPaletted(img *image.Paletted) {
+	bounds := img.Bounds()
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		bufY := buf[(y-bounds.Min.Y)*bounds.Dx():]
+
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			sc := &bufY[x-bounds.Min.X]
+
+			if sc.paletted && int(sc.index) < len(img.Palette) && img.Palette[sc.index] == sc.Color {
+				img.SetColorIndex(x, y, sc.index)
+			} else {
+				img.Set(x, y, sc.Color)
+			}
+		}
+	}
+}
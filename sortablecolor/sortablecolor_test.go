@@ -0,0 +1,211 @@
+package sortablecolor
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// redCombiner keys on the red channel
+type redCombiner struct{}
+
+func (redCombiner) Name() string {
+	return "red"
+}
+
+func (redCombiner) Combine(c color.Color) uint64 {
+	r, _, _, _ := c.RGBA()
+	return uint64(r)
+}
+
+// xCombiner keys on the x coordinate of each pixel when it knows it, and on red otherwise
+type xCombiner struct {
+	redCombiner
+}
+
+func (xCombiner) CombineAt(img image.Image, x, y int) uint64 {
+	return uint64(x)
+}
+
+// atCombiner reads the colors of the image itself, as the neighborhood-aware combiners do
+type atCombiner struct {
+	redCombiner
+}
+
+func (c atCombiner) CombineAt(img image.Image, x, y int) uint64 {
+	return c.Combine(img.At(x, y))
+}
+
+func TestSortableBufferFromImage(t *testing.T) {
+	t.Parallel()
+
+	img := image.NewGray(image.Rect(0, 0, 3, 2))
+	for i := range img.Pix {
+		img.Pix[i] = uint8(0xff - i)
+	}
+
+	buffer, bounds := SortableBufferFromImage(img, xCombiner{})
+	assert.Equal(t, img.Bounds(), bounds)
+
+	keys := make([]uint64, len(buffer))
+	for i := range buffer {
+		keys[i] = buffer[i].v
+		assert.Equal(t, img.At(i%3, i/3), buffer[i].Color)
+	}
+
+	assert.Equal(t, []uint64{0, 1, 2, 0, 1, 2}, keys)
+
+	buffer, _ = SortableBufferFromImage(img, redCombiner{})
+	for i := range buffer {
+		keys[i] = buffer[i].v
+	}
+
+	assert.Equal(t, []uint64{0xffff, 0xfefe, 0xfdfd, 0xfcfc, 0xfbfb, 0xfafa}, keys)
+}
+
+func TestPaletted(t *testing.T) {
+	t.Parallel()
+
+	// The last two entries are the same color, so only their indexes tell them apart
+	palette := color.Palette{
+		color.Gray{Y: 0x20},
+		color.Gray{Y: 0x80},
+		color.Gray{Y: 0xff},
+		color.Gray{Y: 0xff},
+	}
+
+	src := image.NewPaletted(image.Rect(0, 0, 4, 2), palette)
+	copy(src.Pix, []uint8{
+		3, 0, 1, 2,
+		1, 0, 2, 3,
+	})
+
+	for _, test := range []struct {
+		opts     Options
+		expected []uint8
+	}{
+		{
+			opts: Options{Mode: Global},
+			expected: []uint8{
+				0, 0, 1, 1,
+				3, 2, 2, 3,
+			},
+		},
+		{
+			// Only the pixels with the darker two colors are sorted
+			opts: Options{Mode: Intervals, Lower: 0, Upper: 0x8080, Descending: true},
+			expected: []uint8{
+				3, 1, 0, 2,
+				1, 0, 2, 3,
+			},
+		},
+	} {
+		buffer, bounds := SortableBufferFromImage(src, redCombiner{})
+		buffer.Sort(bounds, test.opts)
+
+		dst := NewImageLike(src, bounds, SameDepth)
+		buffer.ToImage(dst)
+
+		paletted, ok := dst.(*image.Paletted)
+		require.True(t, ok)
+		assert.Equal(t, palette, paletted.Palette)
+
+		// Entries 2 and 3 have the same value, so they can end up in either order. Compare them as
+		// one, then check that the indexes themselves were kept (rather than looked up by color).
+		assert.Equal(t, sameColors(test.expected), sameColors(paletted.Pix), "%+v", test.opts)
+		assert.Equal(t, count(src.Pix, 3), count(paletted.Pix, 3), "%+v", test.opts)
+	}
+
+	// A different palette gets the nearest colors
+	buffer, bounds := SortableBufferFromImage(src, redCombiner{})
+	other := image.NewPaletted(bounds, color.Palette{color.Black, color.White})
+	buffer.ToImage(other)
+	assert.Equal(t, []uint8{1, 0, 1, 1, 1, 0, 1, 1}, other.Pix)
+
+	// An index past the end of the palette is taken to be transparent black
+	src.Pix[0] = 9
+	buffer, _ = SortableBufferFromImage(src, redCombiner{})
+	assert.Equal(t, color.Transparent, buffer[0].Color)
+	assert.Equal(t, redCombiner{}.Combine(color.Transparent), buffer[0].v)
+
+	// Including for a combiner.ImageCombiner, which sees it that way too
+	buffer, _ = SortableBufferFromImage(src, atCombiner{})
+	assert.Equal(t, color.Transparent, buffer[0].Color)
+	assert.Equal(t, redCombiner{}.Combine(color.Transparent), buffer[0].v)
+	assert.Equal(t, redCombiner{}.Combine(palette[1]), buffer[2].v)
+	assert.Equal(t, palette, src.Palette)
+}
+
+// sameColors replaces palette index 3 with 2, which has the same color
+func sameColors(pix []uint8) []uint8 {
+	same := make([]uint8, len(pix))
+	for i, idx := range pix {
+		if idx == 3 {
+			idx = 2
+		}
+
+		same[i] = idx
+	}
+
+	return same
+}
+
+// count gives how many times idx appears in pix
+func count(pix []uint8, idx uint8) int {
+	n := 0
+	for _, p := range pix {
+		if p == idx {
+			n++
+		}
+	}
+
+	return n
+}
+
+func TestOffsetBounds(t *testing.T) {
+	t.Parallel()
+
+	// Images such as GIF frames don't have to start at 0,0
+	src := image.NewNRGBA(image.Rect(5, 7, 8, 9))
+	for y := 7; y < 9; y++ {
+		for x := 5; x < 8; x++ {
+			src.SetNRGBA(x, y, color.NRGBA{R: uint8(x), G: uint8(y), A: 0xff})
+		}
+	}
+
+	buffer, bounds := SortableBufferFromImage(src, xCombiner{})
+	assert.Equal(t, src.Bounds(), bounds)
+
+	keys := make([]uint64, len(buffer))
+	for i := range buffer {
+		keys[i] = buffer[i].v
+	}
+
+	assert.Equal(t, []uint64{5, 6, 7, 5, 6, 7}, keys)
+
+	dst := image.NewNRGBA(bounds)
+	buffer.ToImage(dst)
+	assert.Equal(t, src, dst)
+}
+
+func TestOrigin(t *testing.T) {
+	t.Parallel()
+
+	img := image.NewGray(image.Rect(0, 0, 3, 2))
+	for i := range img.Pix {
+		img.Pix[i] = uint8(i * 40)
+	}
+
+	buffer, bounds := SortableBufferFromImage(img, redCombiner{})
+	buffer.Sort(bounds, Options{Mode: Global, Descending: true})
+
+	origins := make([]int, len(buffer))
+	for i := range buffer {
+		origins[i] = buffer[i].Origin()
+	}
+
+	assert.Equal(t, []int{5, 4, 3, 2, 1, 0}, origins)
+}
@@ -0,0 +1,49 @@
+package sortablecolor
+
+// TemporalMask works out which pixels of each frame of a video are in intervals (for
+// Options.Mask), smoothed over time, so that intervals don't jitter from frame to frame as pixels
+// near the thresholds flicker in and out of them. The frames all have to be the same size.
+type TemporalMask struct {
+	// Smoothing is how much of each pixel's value from the frames before it is kept, from 0 (none)
+	// up to (but not including) 1. It's the value of an exponential moving average that's
+	// compared with the thresholds, rather than the pixel's own.
+	Smoothing float64
+
+	// Hysteresis is how far past the thresholds a pixel that was in an interval in the frame before
+	// can go, and still be in one
+	Hysteresis uint64
+
+	values []float64
+	mask   []bool
+}
+
+// Next gives the mask for the next frame, from the buffer that it was read into (before it's
+// sorted), and the thresholds of opts. The mask is reused by the next call.
+func (tm *TemporalMask) Next(buf SortableBuffer, opts Options) []bool {
+	first := len(tm.values) != len(buf)
+	if first {
+		tm.values = make([]float64, len(buf))
+		tm.mask = make([]bool, len(buf))
+	}
+
+	lower, upper := float64(opts.Lower), float64(opts.Upper)
+	hysteresis := float64(tm.Hysteresis)
+
+	for i := range buf {
+		v := float64(buf[i].v)
+		if !first {
+			v = tm.Smoothing*tm.values[i] + (1-tm.Smoothing)*v
+		}
+
+		tm.values[i] = v
+
+		lo, hi := lower, upper
+		if !first && tm.mask[i] {
+			lo, hi = lower-hysteresis, upper+hysteresis
+		}
+
+		tm.mask[i] = lo <= v && v <= hi
+	}
+
+	return tm.mask
+}
@@ -0,0 +1,94 @@
+package sortablecolor
+
+import (
+	"fmt"
+	"image"
+
+	"github.com/dcormier/go-pixelsort/combiner"
+)
+
+// SortableVolume holds the pixels of a sequence of frames that are all the same size, frame after
+// frame (each in row order, as in a SortableBuffer), so that each pixel can be sorted through time
+type SortableVolume struct {
+	Buffer SortableBuffer
+
+	// Bounds are the bounds of every frame
+	Bounds image.Rectangle
+
+	Frames int
+}
+
+// SortableVolumeFromFrames reads frames into a SortableVolume, in the same way as
+// SortableBufferFromImage. The frames have to be the same size; the bounds of the first one are
+// used for all of them.
+func SortableVolumeFromFrames(frames []image.Image, cmb combiner.Combiner) (*SortableVolume, error) {
+	if len(frames) == 0 {
+		return nil, fmt.Errorf("sortablecolor: there are no frames")
+	}
+
+	bounds := frames[0].Bounds()
+	size := bounds.Dx() * bounds.Dy()
+
+	v := &SortableVolume{
+		Buffer: make(SortableBuffer, size*len(frames)),
+		Bounds: bounds,
+		Frames: len(frames),
+	}
+
+	for i, frame := range frames {
+		if frame.Bounds().Size() != bounds.Size() {
+			return nil, fmt.Errorf("sortablecolor: frame %d is %v, but the first frame is %v", i,
+				frame.Bounds().Size(), bounds.Size())
+		}
+
+		buffer, _ := SortableBufferFromImage(frame, cmb)
+		copy(v.Buffer[i*size:], buffer)
+	}
+
+	for i := range v.Buffer {
+		v.Buffer[i].origin = i
+	}
+
+	return v, nil
+}
+
+// Frame gives the pixels of frame i, which can be written out with ToImage
+func (v *SortableVolume) Frame(i int) SortableBuffer {
+	size := v.Bounds.Dx() * v.Bounds.Dy()
+
+	return v.Buffer[i*size : (i+1)*size]
+}
+
+// SortTime sorts each pixel through time, rather than across each frame. With Global mode, all of
+// the frames of each pixel are sorted together; with Intervals mode, runs of frames where the
+// pixel is within the thresholds are (and Mask, if it's set, is by position in the volume). The
+// other modes are across each frame, so they can't be used.
+func (v *SortableVolume) SortTime(opts Options) {
+	size := v.Bounds.Dx() * v.Bounds.Dy()
+
+	for p := 0; p < size; p++ {
+		switch opts.Mode {
+		case Global:
+			opts.sort(&run{buf: v.Buffer, start: p, step: size, n: v.Frames, descending: opts.Descending})
+
+		case Intervals:
+			for t := 0; t < v.Frames; {
+				if !opts.inInterval(v.Buffer, p+t*size) {
+					t++
+					continue
+				}
+
+				start := t
+				for t < v.Frames && opts.inInterval(v.Buffer, p+t*size) {
+					t++
+				}
+
+				opts.sort(&run{buf: v.Buffer, start: p + start*size, step: size, n: t - start,
+					descending: opts.Descending})
+			}
+
+		default:
+			panic(fmt.Sprintf("sortablecolor: can't sort through time in mode %v", opts.Mode))
+		}
+	}
+}
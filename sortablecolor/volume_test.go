@@ -0,0 +1,75 @@
+package sortablecolor
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// grayFrames makes frames of two pixels each, with the given gray levels
+func grayFrames(levels ...[2]uint8) []image.Image {
+	frames := make([]image.Image, len(levels))
+	for i, l := range levels {
+		frame := image.NewGray(image.Rect(5, 5, 7, 6))
+		frame.Pix[0], frame.Pix[1] = l[0], l[1]
+		frames[i] = frame
+	}
+
+	return frames
+}
+
+// pixelOverTime gives the gray levels of pixel p in each frame of the volume
+func pixelOverTime(v *SortableVolume, p int) []uint8 {
+	levels := make([]uint8, v.Frames)
+	for i := range levels {
+		levels[i] = color.GrayModel.Convert(v.Frame(i)[p].Color).(color.Gray).Y
+	}
+
+	return levels
+}
+
+func TestSortTime(t *testing.T) {
+	t.Parallel()
+
+	frames := grayFrames([2]uint8{10, 200}, [2]uint8{30, 100}, [2]uint8{20, 150}, [2]uint8{40, 50})
+
+	v, err := SortableVolumeFromFrames(frames, redCombiner{})
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(5, 5, 7, 6), v.Bounds)
+	assert.Equal(t, 4, v.Frames)
+
+	v.SortTime(Options{Mode: Global, Descending: true})
+	assert.Equal(t, []uint8{40, 30, 20, 10}, pixelOverTime(v, 0))
+	assert.Equal(t, []uint8{200, 150, 100, 50}, pixelOverTime(v, 1))
+
+	// Each pixel knows which frame it came from
+	assert.Equal(t, 3*2, v.Frame(0)[0].Origin())
+
+	sorted := image.NewGray(v.Bounds)
+	v.Frame(3).ToImage(sorted)
+	assert.Equal(t, []uint8{10, 50}, sorted.Pix)
+
+	// Only the runs of frames within the thresholds are sorted
+	v, err = SortableVolumeFromFrames(frames, redCombiner{})
+	require.NoError(t, err)
+
+	v.SortTime(Options{Mode: Intervals, Lower: 0, Upper: 0x9999})
+	assert.Equal(t, []uint8{10, 20, 30, 40}, pixelOverTime(v, 0))
+	assert.Equal(t, []uint8{200, 50, 100, 150}, pixelOverTime(v, 1))
+
+	assert.Panics(t, func() { v.SortTime(Options{Mode: Rows}) })
+}
+
+func TestSortableVolumeFromFramesErrors(t *testing.T) {
+	t.Parallel()
+
+	_, err := SortableVolumeFromFrames(nil, redCombiner{})
+	assert.EqualError(t, err, "sortablecolor: there are no frames")
+
+	frames := append(grayFrames([2]uint8{1, 2}), image.NewGray(image.Rect(0, 0, 3, 1)))
+	_, err = SortableVolumeFromFrames(frames, redCombiner{})
+	assert.EqualError(t, err, "sortablecolor: frame 1 is (3,1), but the first frame is (2,1)")
+}
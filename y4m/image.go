@@ -0,0 +1,30 @@
+package y4m
+
+import (
+	"image"
+	"image/color"
+)
+
+// Image is a 4:4:4 *image.YCbCr that colors can be set in, so that frames can be drawn (or sorted)
+// into it without their samples being converted to RGB and back
+type Image struct {
+	*image.YCbCr
+}
+
+// NewImage gives an Image with the given bounds
+func NewImage(r image.Rectangle) *Image {
+	return &Image{YCbCr: image.NewYCbCr(r, image.YCbCrSubsampleRatio444)}
+}
+
+// Set sets the color of the pixel at (x, y). The samples of color.YCbCr colors are kept as they are,
+// and other colors are converted.
+func (img *Image) Set(x, y int, c color.Color) {
+	if !(image.Point{X: x, Y: y}.In(img.Rect)) {
+		return
+	}
+
+	ycc := toYCbCr(c)
+
+	yi, ci := img.YOffset(x, y), img.COffset(x, y)
+	img.Y[yi], img.Cb[ci], img.Cr[ci] = ycc.Y, ycc.Cb, ycc.Cr
+}
@@ -0,0 +1,98 @@
+package y4m
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"strings"
+)
+
+// Reader reads the frames of a stream, one at a time
+type Reader struct {
+	r      *bufio.Reader
+	header Header
+}
+
+// NewReader reads the header of a stream from r, and gives a Reader for its frames
+func NewReader(r io.Reader) (*Reader, error) {
+	br, ok := r.(*bufio.Reader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+
+	line, err := readLine(br)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	header, err := parseHeader(line)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Reader{r: br, header: header}, nil
+}
+
+// Header gives the header of the stream
+func (r *Reader) Header() Header {
+	return r.header
+}
+
+func (r *Reader) config() image.Config {
+	model := color.YCbCrModel
+	if r.header.colorspace() == Mono {
+		model = color.GrayModel
+	}
+
+	return image.Config{ColorModel: model, Width: r.header.Width, Height: r.header.Height}
+}
+
+// ReadFrame reads the next frame. It's an *image.Gray for mono streams, and an *image.YCbCr
+// otherwise. At the end of the stream, io.EOF is returned; if the stream ends part of the way
+// through a frame, io.ErrUnexpectedEOF is.
+func (r *Reader) ReadFrame() (image.Image, error) {
+	line, err := readLine(r.r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Frames can have parameters of their own, which are all optional, so they're skipped
+	if line != "FRAME" && !strings.HasPrefix(line, "FRAME ") {
+		return nil, fmt.Errorf("y4m: expected a frame, found %.20q", line)
+	}
+
+	bounds := image.Rect(0, 0, r.header.Width, r.header.Height)
+
+	if r.header.colorspace() == Mono {
+		frame := image.NewGray(bounds)
+
+		return frame, r.readPlanes(frame.Pix)
+	}
+
+	ratio, _ := r.header.colorspace().subsampleRatio()
+	frame := image.NewYCbCr(bounds, ratio)
+
+	return frame, r.readPlanes(frame.Y, frame.Cb, frame.Cr)
+}
+
+// readPlanes reads the samples of a frame. image.NewGray and image.NewYCbCr lay their planes out
+// just as they are in the stream, with no gaps between rows.
+func (r *Reader) readPlanes(planes ...[]byte) error {
+	for _, plane := range planes {
+		if _, err := io.ReadFull(r.r, plane); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,116 @@
+package y4m
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+)
+
+// Writer writes frames to a stream, one at a time
+type Writer struct {
+	w      io.Writer
+	header Header
+
+	// planes is reused for the samples of each frame
+	planes []byte
+}
+
+// NewWriter writes the header of a stream to w, and gives a Writer for its frames
+func NewWriter(w io.Writer, header Header) (*Writer, error) {
+	if header.Width <= 0 || header.Height <= 0 {
+		return nil, fmt.Errorf("y4m: can't write frames that are %dx%d", header.Width, header.Height)
+	}
+
+	if _, ok := header.colorspace().subsampleRatio(); !ok && header.colorspace() != Mono {
+		return nil, fmt.Errorf("y4m: unsupported colorspace %q", header.Colorspace)
+	}
+
+	if _, err := io.WriteString(w, header.String()+"\n"); err != nil {
+		return nil, err
+	}
+
+	return &Writer{w: w, header: header, planes: make([]byte, header.FrameSize())}, nil
+}
+
+// WriteFrame writes img as the next frame. It has to be the size that the header says. Colors are
+// converted to the colorspace of the stream, with chroma averaged over each block of pixels that
+// shares it; the samples of color.YCbCr colors are used as they are.
+func (w *Writer) WriteFrame(img image.Image) error {
+	bounds := img.Bounds()
+	if bounds.Dx() != w.header.Width || bounds.Dy() != w.header.Height {
+		return fmt.Errorf("y4m: the frame is %dx%d, but the stream is %dx%d", bounds.Dx(), bounds.Dy(),
+			w.header.Width, w.header.Height)
+	}
+
+	if _, err := io.WriteString(w.w, "FRAME\n"); err != nil {
+		return err
+	}
+
+	if w.header.colorspace() == Mono {
+		w.fillMono(img)
+	} else {
+		w.fillYCbCr(img)
+	}
+
+	_, err := w.w.Write(w.planes)
+
+	return err
+}
+
+func (w *Writer) fillMono(img image.Image) {
+	bounds := img.Bounds()
+
+	i := 0
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			w.planes[i] = color.GrayModel.Convert(img.At(x, y)).(color.Gray).Y
+			i++
+		}
+	}
+}
+
+func (w *Writer) fillYCbCr(img image.Image) {
+	bounds := img.Bounds()
+	ratio, _ := w.header.colorspace().subsampleRatio()
+
+	width, height := bounds.Dx(), bounds.Dy()
+	cw, ch := chromaSize(width, height, ratio)
+
+	// How many pixels across and down share each chroma sample
+	bw, bh := (width+cw-1)/cw, (height+ch-1)/ch
+
+	yPlane := w.planes[:width*height]
+	cbPlane := w.planes[width*height : width*height+cw*ch]
+	crPlane := w.planes[width*height+cw*ch:]
+
+	cbSums := make([]int, cw*ch)
+	crSums := make([]int, cw*ch)
+	counts := make([]int, cw*ch)
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			c := toYCbCr(img.At(bounds.Min.X+x, bounds.Min.Y+y))
+
+			yPlane[y*width+x] = c.Y
+
+			ci := (y/bh)*cw + x/bw
+			cbSums[ci] += int(c.Cb)
+			crSums[ci] += int(c.Cr)
+			counts[ci]++
+		}
+	}
+
+	for i, n := range counts {
+		cbPlane[i] = uint8((cbSums[i] + n/2) / n)
+		crPlane[i] = uint8((crSums[i] + n/2) / n)
+	}
+}
+
+func toYCbCr(c color.Color) color.YCbCr {
+	if ycc, ok := c.(color.YCbCr); ok {
+		return ycc
+	}
+
+	return color.YCbCrModel.Convert(c).(color.YCbCr)
+}
@@ -0,0 +1,291 @@
+// Package y4m reads and writes YUV4MPEG2 (.y4m) video, one frame at a time. It's the raw video
+// format that ffmpeg and most other video tools can pipe in and out, such as with
+// "ffmpeg -i in.mp4 -f yuv4mpegpipe -".
+//
+// Only 8-bit video is supported. Samples are kept as they are, rather than being expanded from the
+// limited range that most video uses, so such video reads as a little washed out, but comes back
+// out the same. Importing this package also registers the format with the image package, so
+// image.Decode gives the first frame of a .y4m file.
+package y4m
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Magic starts every YUV4MPEG2 stream
+const Magic = "YUV4MPEG2"
+
+// maxLine is the longest header or frame header line that's read
+const maxLine = 4096
+
+func init() {
+	image.RegisterFormat("y4m", Magic, Decode, DecodeConfig)
+}
+
+// Colorspace is the layout of the samples of each frame
+type Colorspace string
+
+const (
+	// C420jpeg is 4:2:0 with chroma centered between luma samples; it's the default
+	C420jpeg Colorspace = "420jpeg"
+
+	// C420paldv is 4:2:0 with PAL DV chroma siting
+	C420paldv Colorspace = "420paldv"
+
+	// C420mpeg2 is 4:2:0 with MPEG-2 chroma siting
+	C420mpeg2 Colorspace = "420mpeg2"
+
+	// C420 is 4:2:0 with chroma sited with luma
+	C420 Colorspace = "420"
+
+	// C422 has chroma at half of the horizontal resolution
+	C422 Colorspace = "422"
+
+	// C444 has chroma at full resolution
+	C444 Colorspace = "444"
+
+	// Mono only has luma
+	Mono Colorspace = "mono"
+)
+
+// subsampleRatio gives how the chroma of the colorspace is subsampled
+func (c Colorspace) subsampleRatio() (image.YCbCrSubsampleRatio, bool) {
+	switch c {
+	case C420jpeg, C420paldv, C420mpeg2, C420:
+		return image.YCbCrSubsampleRatio420, true
+	case C422:
+		return image.YCbCrSubsampleRatio422, true
+	case C444:
+		return image.YCbCrSubsampleRatio444, true
+	}
+
+	return 0, false
+}
+
+// Ratio is a ratio such as a frame rate (30000:1001) or a pixel aspect ratio (1:1). The zero
+// Ratio means that it's unknown.
+type Ratio struct {
+	Num, Den int
+}
+
+func (r Ratio) String() string {
+	return fmt.Sprintf("%d:%d", r.Num, r.Den)
+}
+
+func parseRatio(s string) (Ratio, error) {
+	colon := strings.IndexByte(s, ':')
+	if colon < 0 {
+		return Ratio{}, fmt.Errorf("expected a ratio such as 30:1, found %q", s)
+	}
+
+	num, err := strconv.Atoi(s[:colon])
+	if err != nil || num < 0 {
+		return Ratio{}, fmt.Errorf("expected a ratio such as 30:1, found %q", s)
+	}
+
+	den, err := strconv.Atoi(s[colon+1:])
+	if err != nil || den < 0 {
+		return Ratio{}, fmt.Errorf("expected a ratio such as 30:1, found %q", s)
+	}
+
+	return Ratio{Num: num, Den: den}, nil
+}
+
+// Header describes a stream. Writing a stream with the Header that it was read with keeps
+// everything about it.
+type Header struct {
+	Width, Height int
+
+	// FrameRate is the number of frames per second, as a ratio
+	FrameRate Ratio
+
+	// Interlacing is 'p' (progressive), 't' (top field first), 'b' (bottom field first), 'm'
+	// (mixed), or 0 if it isn't given
+	Interlacing byte
+
+	// PixelAspect is the shape of each pixel
+	PixelAspect Ratio
+
+	// Colorspace is the layout of the samples of each frame; if it's empty, it's C420jpeg
+	Colorspace Colorspace
+
+	// Extra are the parameters that applications have added (such as "COLORRANGE=LIMITED" for
+	// "XCOLORRANGE=LIMITED"), without their leading "X"
+	Extra []string
+}
+
+// colorspace is the colorspace, with the default filled in
+func (h Header) colorspace() Colorspace {
+	if h.Colorspace == "" {
+		return C420jpeg
+	}
+
+	return h.Colorspace
+}
+
+// FrameSize gives the number of bytes of samples in each frame
+func (h Header) FrameSize() int {
+	if h.colorspace() == Mono {
+		return h.Width * h.Height
+	}
+
+	ratio, _ := h.colorspace().subsampleRatio()
+	cw, ch := chromaSize(h.Width, h.Height, ratio)
+
+	return h.Width*h.Height + 2*cw*ch
+}
+
+func (h Header) String() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%s W%d H%d", Magic, h.Width, h.Height)
+
+	if h.FrameRate != (Ratio{}) {
+		fmt.Fprintf(&b, " F%v", h.FrameRate)
+	}
+
+	if h.Interlacing != 0 {
+		fmt.Fprintf(&b, " I%c", h.Interlacing)
+	}
+
+	if h.PixelAspect != (Ratio{}) {
+		fmt.Fprintf(&b, " A%v", h.PixelAspect)
+	}
+
+	if h.Colorspace != "" {
+		fmt.Fprintf(&b, " C%s", h.Colorspace)
+	}
+
+	for _, x := range h.Extra {
+		fmt.Fprintf(&b, " X%s", x)
+	}
+
+	return b.String()
+}
+
+// parseHeader parses the first line of a stream, without its newline
+func parseHeader(line string) (Header, error) {
+	fields := strings.Split(line, " ")
+	if fields[0] != Magic {
+		return Header{}, fmt.Errorf("y4m: not a %s stream", Magic)
+	}
+
+	var (
+		h   Header
+		err error
+	)
+
+	for _, field := range fields[1:] {
+		if field == "" {
+			continue
+		}
+
+		value := field[1:]
+
+		switch field[0] {
+		case 'W':
+			h.Width, err = strconv.Atoi(value)
+		case 'H':
+			h.Height, err = strconv.Atoi(value)
+		case 'F':
+			h.FrameRate, err = parseRatio(value)
+		case 'A':
+			h.PixelAspect, err = parseRatio(value)
+		case 'I':
+			if len(value) != 1 {
+				err = fmt.Errorf("unknown interlacing %q", value)
+			} else {
+				h.Interlacing = value[0]
+			}
+		case 'C':
+			h.Colorspace = Colorspace(value)
+		case 'X':
+			h.Extra = append(h.Extra, value)
+		default:
+			err = fmt.Errorf("unknown parameter %q", field)
+		}
+
+		if err != nil {
+			return Header{}, fmt.Errorf("y4m: bad header: %v", err)
+		}
+	}
+
+	if h.Width <= 0 || h.Height <= 0 {
+		return Header{}, fmt.Errorf("y4m: bad header: the size is %dx%d", h.Width, h.Height)
+	}
+
+	if _, ok := h.colorspace().subsampleRatio(); !ok && h.colorspace() != Mono {
+		return Header{}, fmt.Errorf("y4m: unsupported colorspace %q (only 8-bit 420, 422, 444 and mono are)",
+			h.Colorspace)
+	}
+
+	return h, nil
+}
+
+// chromaSize gives the size of each chroma plane of a frame
+func chromaSize(width, height int, ratio image.YCbCrSubsampleRatio) (int, int) {
+	switch ratio {
+	case image.YCbCrSubsampleRatio420:
+		return (width + 1) / 2, (height + 1) / 2
+	case image.YCbCrSubsampleRatio422:
+		return (width + 1) / 2, height
+	}
+
+	return width, height
+}
+
+// readLine reads a line that ends with a newline, which isn't included. If the stream ends part of
+// the way through the line, io.ErrUnexpectedEOF is returned.
+func readLine(r *bufio.Reader) (string, error) {
+	var line []byte
+
+	for {
+		part, err := r.ReadSlice('\n')
+		line = append(line, part...)
+
+		if len(line) > maxLine {
+			return "", fmt.Errorf("y4m: a header is longer than %d bytes", maxLine)
+		}
+
+		switch {
+		case err == nil:
+			return string(line[:len(line)-1]), nil
+
+		case err == io.EOF && len(line) > 0:
+			return "", io.ErrUnexpectedEOF
+
+		case err != bufio.ErrBufferFull:
+			return "", err
+		}
+	}
+}
+
+// Decode reads the first frame of a stream
+func Decode(r io.Reader) (image.Image, error) {
+	yr, err := NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	frame, err := yr.ReadFrame()
+	if err == io.EOF {
+		return nil, fmt.Errorf("y4m: the stream has no frames")
+	}
+
+	return frame, err
+}
+
+// DecodeConfig gives the color model and size of the frames of a stream
+func DecodeConfig(r io.Reader) (image.Config, error) {
+	yr, err := NewReader(r)
+	if err != nil {
+		return image.Config{}, err
+	}
+
+	return yr.config(), nil
+}
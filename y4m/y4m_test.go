@@ -0,0 +1,206 @@
+package y4m
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"io"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testStream makes a stream with the given header and frames of random samples
+func testStream(t *testing.T, header string, frames int) []byte {
+	h, err := parseHeader(header)
+	require.NoError(t, err)
+
+	rnd := rand.New(rand.NewSource(1))
+
+	stream := bytes.NewBufferString(header + "\n")
+	for i := 0; i < frames; i++ {
+		samples := make([]byte, h.FrameSize())
+		rnd.Read(samples)
+
+		stream.WriteString("FRAME\n")
+		stream.Write(samples)
+	}
+
+	return stream.Bytes()
+}
+
+func TestHeader(t *testing.T) {
+	t.Parallel()
+
+	const line = "YUV4MPEG2 W5 H3 F30000:1001 Ip A1:1 C420jpeg XYSCSS=420JPEG XCOLORRANGE=LIMITED"
+
+	h, err := parseHeader(line)
+	require.NoError(t, err)
+	assert.Equal(t, Header{
+		Width:       5,
+		Height:      3,
+		FrameRate:   Ratio{Num: 30000, Den: 1001},
+		Interlacing: 'p',
+		PixelAspect: Ratio{Num: 1, Den: 1},
+		Colorspace:  C420jpeg,
+		Extra:       []string{"YSCSS=420JPEG", "COLORRANGE=LIMITED"},
+	}, h)
+	assert.Equal(t, line, h.String())
+
+	assert.Equal(t, 5*3+2*3*2, h.FrameSize())
+	assert.Equal(t, 5*3+2*3*3, Header{Width: 5, Height: 3, Colorspace: C422}.FrameSize())
+	assert.Equal(t, 5*3*3, Header{Width: 5, Height: 3, Colorspace: C444}.FrameSize())
+	assert.Equal(t, 5*3, Header{Width: 5, Height: 3, Colorspace: Mono}.FrameSize())
+	assert.Equal(t, "YUV4MPEG2 W5 H3", Header{Width: 5, Height: 3}.String())
+
+	for line, msg := range map[string]string{
+		"YUV4MPEG W5 H3":          "y4m: not a YUV4MPEG2 stream",
+		"YUV4MPEG2 W5":            "y4m: bad header: the size is 5x0",
+		"YUV4MPEG2 Wfive H3":      `y4m: bad header: strconv.Atoi: parsing "five": invalid syntax`,
+		"YUV4MPEG2 W5 H3 F30":     `y4m: bad header: expected a ratio such as 30:1, found "30"`,
+		"YUV4MPEG2 W5 H3 Q1":      `y4m: bad header: unknown parameter "Q1"`,
+		"YUV4MPEG2 W5 H3 C420p10": `y4m: unsupported colorspace "420p10" (only 8-bit 420, 422, 444 and mono are)`,
+	} {
+		_, err := parseHeader(line)
+		assert.EqualError(t, err, msg, line)
+	}
+}
+
+// TestRoundTrip makes sure that streams come back out exactly as they were read
+func TestRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	for _, header := range []string{
+		"YUV4MPEG2 W5 H3 F25:1 Ip A1:1",
+		"YUV4MPEG2 W6 H4 C420mpeg2 XCOLORRANGE=FULL",
+		"YUV4MPEG2 W5 H3 C422",
+		"YUV4MPEG2 W5 H3 C444",
+		"YUV4MPEG2 W5 H3 Cmono",
+	} {
+		stream := testStream(t, header, 3)
+
+		r, err := NewReader(bytes.NewReader(stream))
+		require.NoError(t, err, header)
+
+		out := &bytes.Buffer{}
+		w, err := NewWriter(out, r.Header())
+		require.NoError(t, err, header)
+
+		frames := 0
+		for {
+			frame, err := r.ReadFrame()
+			if err == io.EOF {
+				break
+			}
+
+			require.NoError(t, err, header)
+			require.NoError(t, w.WriteFrame(frame), header)
+			frames++
+		}
+
+		assert.Equal(t, 3, frames, header)
+		assert.Equal(t, stream, out.Bytes(), header)
+	}
+}
+
+func TestReadFrame(t *testing.T) {
+	t.Parallel()
+
+	stream := []byte("YUV4MPEG2 W2 H2 C420\nFRAME Ixyz\n\x10\x20\x30\x40\x80\xc0")
+
+	r, err := NewReader(bytes.NewReader(stream))
+	require.NoError(t, err)
+
+	frame, err := r.ReadFrame()
+	require.NoError(t, err)
+	assert.Equal(t, color.YCbCr{Y: 0x40, Cb: 0x80, Cr: 0xc0}, frame.At(1, 1))
+
+	_, err = r.ReadFrame()
+	assert.Equal(t, io.EOF, err)
+
+	// A stream that stops part of the way through a frame
+	r, err = NewReader(bytes.NewReader(stream[:len(stream)-1]))
+	require.NoError(t, err)
+
+	_, err = r.ReadFrame()
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+
+	r, err = NewReader(bytes.NewReader([]byte("YUV4MPEG2 W2 H2\nFRAMES\n")))
+	require.NoError(t, err)
+
+	_, err = r.ReadFrame()
+	assert.EqualError(t, err, `y4m: expected a frame, found "FRAMES"`)
+
+	_, err = NewReader(bytes.NewReader([]byte("YUV4MPEG2 W2 H2")))
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+}
+
+func TestDecode(t *testing.T) {
+	t.Parallel()
+
+	stream := testStream(t, "YUV4MPEG2 W5 H3 C444", 2)
+
+	img, format, err := image.Decode(bytes.NewReader(stream))
+	require.NoError(t, err)
+	assert.Equal(t, "y4m", format)
+	assert.Equal(t, image.Rect(0, 0, 5, 3), img.Bounds())
+
+	config, format, err := image.DecodeConfig(bytes.NewReader(stream))
+	require.NoError(t, err)
+	assert.Equal(t, "y4m", format)
+	assert.Equal(t, image.Config{ColorModel: color.YCbCrModel, Width: 5, Height: 3}, config)
+
+	_, err = Decode(bytes.NewReader([]byte("YUV4MPEG2 W5 H3\n")))
+	assert.EqualError(t, err, "y4m: the stream has no frames")
+}
+
+func TestWriteFrame(t *testing.T) {
+	t.Parallel()
+
+	img := image.NewNRGBA(image.Rect(10, 10, 12, 12))
+	img.SetNRGBA(10, 10, color.NRGBA{R: 0xff, A: 0xff})
+	img.SetNRGBA(11, 10, color.NRGBA{G: 0xff, A: 0xff})
+	img.SetNRGBA(10, 11, color.NRGBA{B: 0xff, A: 0xff})
+	img.SetNRGBA(11, 11, color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff})
+
+	out := &bytes.Buffer{}
+	w, err := NewWriter(out, Header{Width: 2, Height: 2})
+	require.NoError(t, err)
+	require.NoError(t, w.WriteFrame(img))
+
+	frame, err := Decode(bytes.NewReader(out.Bytes()))
+	require.NoError(t, err)
+
+	// Luma is kept for every pixel, and chroma is averaged over all four
+	for i, c := range []color.NRGBA{img.NRGBAAt(10, 10), img.NRGBAAt(11, 10), img.NRGBAAt(10, 11), img.NRGBAAt(11, 11)} {
+		expected := color.YCbCrModel.Convert(c).(color.YCbCr)
+		assert.Equal(t, expected.Y, frame.At(i%2, i/2).(color.YCbCr).Y)
+	}
+
+	assert.InDelta(t, 0x80, frame.(*image.YCbCr).Cb[0], 2)
+	assert.InDelta(t, 0x80, frame.(*image.YCbCr).Cr[0], 2)
+
+	assert.EqualError(t, w.WriteFrame(image.NewGray(image.Rect(0, 0, 3, 2))),
+		"y4m: the frame is 3x2, but the stream is 2x2")
+
+	_, err = NewWriter(out, Header{Width: 2, Height: 2, Colorspace: "444alpha"})
+	assert.EqualError(t, err, `y4m: unsupported colorspace "444alpha"`)
+}
+
+func TestImage(t *testing.T) {
+	t.Parallel()
+
+	img := NewImage(image.Rect(1, 1, 3, 3))
+
+	c := color.YCbCr{Y: 0x12, Cb: 0x34, Cr: 0x56}
+	img.Set(2, 2, c)
+	assert.Equal(t, c, img.At(2, 2))
+
+	img.Set(1, 1, color.Gray{Y: 0x80})
+	assert.Equal(t, color.YCbCr{Y: 0x80, Cb: 0x80, Cr: 0x80}, img.At(1, 1))
+
+	// Pixels outside of the image are ignored
+	img.Set(0, 0, c)
+}